@@ -8,12 +8,15 @@ require (
 	github.com/go-logfmt/logfmt v0.6.0 // indirect
 	github.com/hashicorp/go-slug v0.16.1 // indirect
 	github.com/hashicorp/go-tfe v1.70.0
+	github.com/hashicorp/go-version v1.7.0
+	github.com/hashicorp/jsonapi v1.3.1
 	github.com/prometheus/client_golang v1.20.5
 	github.com/prometheus/client_model v0.6.1
 	github.com/smartystreets/goconvey v1.6.4
 	golang.org/x/sync v0.9.0
 	golang.org/x/sys v0.27.0 // indirect
-	golang.org/x/time v0.8.0 // indirect
+	golang.org/x/time v0.8.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 replace github.com/ryancbutler/terraform-cloud-exporter => /mnt/c/Users/ryan.butler/Gitprojects/terraform-cloud-exporter
@@ -0,0 +1,186 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// auditTrail is the Metric subsystem we use.
+	auditTrailSubsystem = "audit_trail"
+)
+
+// Metric descriptors.
+var (
+	AuditTrailEvents = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, auditTrailSubsystem, "events_total"),
+		"Number of audit trail events seen since the exporter started.",
+		[]string{"organization", "resource_type", "action"}, nil,
+	)
+)
+
+type auditTrailKey struct {
+	organization string
+	resourceType string
+	action       string
+}
+
+// auditTrailState carries the audit trail cursor and counters between scrapes.
+type auditTrailState struct {
+	sync.Mutex
+	// since is the timestamp of the newest event already counted.
+	since time.Time
+	// seen holds the IDs of the events at the since timestamp, the API returns them again on the next call.
+	seen   map[string]bool
+	counts map[auditTrailKey]float64
+	// orgNames resolves the organization IDs used by the audit trail to organization names.
+	orgNames map[string]string
+}
+
+// ScrapeAuditTrail scrapes the audit trail events of the organization owning the audit trail token.
+type ScrapeAuditTrail struct {
+	state *auditTrailState
+}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeAuditTrail{}.withNewState())
+}
+
+func (ScrapeAuditTrail) withNewState() Scraper {
+	return ScrapeAuditTrail{state: newAuditTrailState()}
+}
+
+func newAuditTrailState() *auditTrailState {
+	return &auditTrailState{
+		seen:     map[string]bool{},
+		counts:   map[auditTrailKey]float64{},
+		orgNames: map[string]string{},
+	}
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeAuditTrail) Name() string {
+	return auditTrailSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeAuditTrail) Help() string {
+	return "Scrape information from the Audit Trails API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/audit-trails"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeAuditTrail) Version() string {
+	return "v2"
+}
+
+// getOrganizationName resolves an organization ID, looking up the configured organizations on cache misses.
+func (s ScrapeAuditTrail) getOrganizationName(ctx context.Context, id string, config *setup.Config) string {
+	if name, ok := s.state.orgNames[id]; ok {
+		return name
+	}
+
+	for _, name := range config.Organizations {
+		o, err := config.Client.Organizations.Read(ctx, name)
+		if err != nil {
+			continue
+		}
+		s.state.orgNames[o.ExternalID] = o.Name
+	}
+
+	if _, ok := s.state.orgNames[id]; !ok {
+		// Don't look it up again, the token belongs to an organization we aren't configured for.
+		s.state.orgNames[id] = id
+	}
+	return s.state.orgNames[id]
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (s ScrapeAuditTrail) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	// Reading the audit trail is opt-in, it requires its own organization token.
+	if config.AuditTrailClient == nil {
+		return nil
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	if s.state.since.IsZero() {
+		// Only count the events happening while the exporter runs.
+		s.state.since = now()
+	}
+
+	// The state is only updated once every page was read, a failed scrape reads them all again the next time.
+	since, newest := s.state.since, s.state.since
+	counts := map[auditTrailKey]float64{}
+	seen := map[string]bool{}
+	for page := 1; ; page++ {
+		events, err := config.AuditTrailClient.AuditTrails.List(ctx, &tfe.AuditTrailListOptions{
+			Since:       since,
+			ListOptions: &tfe.ListOptions{PageSize: config.APIPageSize, PageNumber: page},
+		})
+		if err != nil {
+			return fmt.Errorf("%v, (page=%d)", err, page)
+		}
+
+		for _, e := range events.Items {
+			if s.state.seen[e.ID] || seen[e.ID] {
+				continue
+			}
+
+			key := auditTrailKey{
+				organization: s.getOrganizationName(ctx, e.Auth.OrganizationID, config),
+				resourceType: e.Resource.Type,
+				action:       e.Resource.Action,
+			}
+			counts[key]++
+
+			if e.Timestamp.After(newest) {
+				newest = e.Timestamp
+				seen = map[string]bool{}
+			}
+			if e.Timestamp.Equal(newest) {
+				seen[e.ID] = true
+			}
+		}
+
+		if events.AuditTrailPagination == nil || page >= events.AuditTrailPagination.TotalPages {
+			break
+		}
+	}
+
+	for key, count := range counts {
+		s.state.counts[key] += count
+	}
+	if newest.After(since) {
+		s.state.since = newest
+		s.state.seen = seen
+	} else {
+		for id := range seen {
+			s.state.seen[id] = true
+		}
+	}
+
+	for key, count := range s.state.counts {
+		select {
+		case ch <- prometheus.MustNewConstMetric(
+			AuditTrailEvents,
+			prometheus.CounterValue,
+			count,
+			key.organization,
+			key.resourceType,
+			key.action,
+		):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
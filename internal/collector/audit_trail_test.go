@@ -0,0 +1,183 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeAuditTrail(t *testing.T) {
+	now = func() time.Time { return time.Date(2020, 10, 10, 10, 10, 10, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organization/audit-trail", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+		if calls == 1 {
+			w.Write([]byte(`{
+				"data":[
+					{"id":"ae-1","timestamp":"2020-10-10T10:10:20Z","auth":{"organization_id":"org-1"},"resource":{"type":"workspace","action":"destroy"}},
+					{"id":"ae-2","timestamp":"2020-10-10T10:10:30Z","auth":{"organization_id":"org-1"},"resource":{"type":"authentication-token","action":"create"}}
+				],
+				"pagination":{"current_page":1,"total_pages":1,"total_count":2}
+			}`))
+			return
+		}
+		if r.URL.Query().Get("since") != "2020-10-10T10:10:30Z" {
+			t.Errorf("unexpected since cursor: %s", r.URL.Query().Get("since"))
+		}
+		w.Write([]byte(`{
+			"data":[
+				{"id":"ae-2","timestamp":"2020-10-10T10:10:30Z","auth":{"organization_id":"org-1"},"resource":{"type":"authentication-token","action":"create"}},
+				{"id":"ae-3","timestamp":"2020-10-10T10:10:40Z","auth":{"organization_id":"org-1"},"resource":{"type":"authentication-token","action":"create"}}
+			],
+			"pagination":{"current_page":1,"total_pages":1,"total_count":2}
+		}`))
+	})
+	mux.HandleFunc("/api/v2/organizations/test-org", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"id":"test-org","type":"organizations","attributes":{"external-id":"org-1"}}}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client:           *client,
+		AuditTrailClient: client,
+		CLI:              setup.CLI{Organizations: []string{"test-org"}},
+	}
+
+	scraper := ScrapeAuditTrail{state: newAuditTrailState()}
+	scrape := func() map[string]float64 {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			if err := scraper.Scrape(context.Background(), config, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+		}()
+
+		got := map[string]float64{}
+		for m := range ch {
+			r := readMetric(m)
+			convey.So(r.metricType, convey.ShouldEqual, dto.MetricType_COUNTER)
+			convey.So(r.labels["organization"], convey.ShouldEqual, "test-org")
+			got[r.labels["resource_type"]+"/"+r.labels["action"]] = r.value
+		}
+		return got
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		convey.So(scrape(), convey.ShouldResemble, map[string]float64{"workspace/destroy": 1, "authentication-token/create": 1})
+		convey.So(scrape(), convey.ShouldResemble, map[string]float64{"workspace/destroy": 1, "authentication-token/create": 2})
+	})
+}
+
+func TestScrapeAuditTrailFailedPage(t *testing.T) {
+	now = func() time.Time { return time.Date(2020, 10, 10, 10, 10, 10, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	failed := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organization/audit-trail", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("since") != "2020-10-10T10:10:10Z" {
+			t.Errorf("unexpected since cursor: %s", r.URL.Query().Get("since"))
+		}
+		if r.URL.Query().Get("page[number]") == "2" {
+			if !failed {
+				failed = true
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+				"data":[
+					{"id":"ae-2","timestamp":"2020-10-10T10:10:30Z","auth":{"organization_id":"org-1"},"resource":{"type":"workspace","action":"destroy"}}
+				],
+				"pagination":{"current_page":2,"total_pages":2,"total_count":2}
+			}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"data":[
+				{"id":"ae-1","timestamp":"2020-10-10T10:10:20Z","auth":{"organization_id":"org-1"},"resource":{"type":"workspace","action":"destroy"}}
+			],
+			"pagination":{"current_page":1,"total_pages":2,"total_count":2}
+		}`))
+	})
+	mux.HandleFunc("/api/v2/organizations/test-org", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"id":"test-org","type":"organizations","attributes":{"external-id":"org-1"}}}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client:           *client,
+		AuditTrailClient: client,
+		CLI:              setup.CLI{Organizations: []string{"test-org"}},
+	}
+
+	scraper := ScrapeAuditTrail{state: newAuditTrailState()}
+	scrape := func() (map[string]float64, error) {
+		ch := make(chan prometheus.Metric)
+		var err error
+		go func() {
+			defer close(ch)
+			err = scraper.Scrape(context.Background(), config, ch)
+		}()
+
+		got := map[string]float64{}
+		for m := range ch {
+			r := readMetric(m)
+			got[r.labels["resource_type"]+"/"+r.labels["action"]] = r.value
+		}
+		return got, err
+	}
+
+	convey.Convey("A failed page leaves the state untouched", t, func() {
+		_, err := scrape()
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(scraper.state.counts, convey.ShouldBeEmpty)
+		convey.So(scraper.state.since, convey.ShouldResemble, now())
+
+		got, err := scrape()
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(got, convey.ShouldResemble, map[string]float64{"workspace/destroy": 2})
+	})
+}
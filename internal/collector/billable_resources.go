@@ -0,0 +1,219 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// billableResources is the Metric subsystem we use.
+	billableResourcesSubsystem = "billable_resources"
+)
+
+// Metric descriptors.
+var (
+	BillableResources = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "billable_resources"),
+		"Estimated number of billable managed resources (RUM), excluding data sources, null_resource and terraform_data.",
+		[]string{"organization", "project"}, nil,
+	)
+	BillableResourcesHourlyPeak = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "billable_resources_hourly_peak"),
+		"Highest number of billable managed resources seen during the current hour.",
+		[]string{"organization", "project"}, nil,
+	)
+)
+
+// nonBillableResourceTypes are the managed resources HashiCorp doesn't bill.
+var nonBillableResourceTypes = map[string]bool{
+	"null_resource":  true,
+	"terraform_data": true,
+}
+
+type billableResourcesKey struct {
+	organization string
+	project      string
+}
+
+// billablePeaks carries the hourly peak of billable resources between scrapes.
+type billablePeaks struct {
+	sync.Mutex
+	hour  time.Time
+	peaks map[billableResourcesKey]int
+}
+
+// observe records a count and returns the peak of the current hour.
+func (p *billablePeaks) observe(key billableResourcesKey, count int) int {
+	p.Lock()
+	defer p.Unlock()
+
+	if hour := now().Truncate(time.Hour); !hour.Equal(p.hour) {
+		p.hour = hour
+		p.peaks = map[billableResourcesKey]int{}
+	}
+
+	if count > p.peaks[key] {
+		p.peaks[key] = count
+	}
+	return p.peaks[key]
+}
+
+// ScrapeBillableResources estimates the billable managed resources of the organizations.
+type ScrapeBillableResources struct {
+	peaks *billablePeaks
+}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeBillableResources{}.withNewState())
+}
+
+func (ScrapeBillableResources) withNewState() Scraper {
+	return ScrapeBillableResources{peaks: &billablePeaks{}}
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeBillableResources) Name() string {
+	return billableResourcesSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeBillableResources) Help() string {
+	return "Estimate billable resources from the Workspace Resources API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/workspace-resources"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeBillableResources) Version() string {
+	return "v2"
+}
+
+// countBillableResources returns the number of billable resources managed by a workspace.
+func countBillableResources(ctx context.Context, w *tfe.Workspace, organization string, config *setup.Config) (int, error) {
+	count := 0
+	for page := 1; ; page++ {
+		resources, err := config.Client.WorkspaceResources.List(ctx, w.ID, &tfe.WorkspaceResourceListOptions{
+			ListOptions: tfe.ListOptions{PageSize: config.APIPageSize, PageNumber: page},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("%v, (organization=%s, workspace=%s, page=%d)", err, organization, w.Name, page)
+		}
+
+		for _, r := range resources.Items {
+			if isBillableResource(r) {
+				count++
+			}
+		}
+		if resources.Pagination == nil || page >= resources.Pagination.TotalPages {
+			return count, nil
+		}
+	}
+}
+
+func (s ScrapeBillableResources) getBillableResources(ctx context.Context, organization string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	workspaces, err := listWorkspaces(ctx, organization, config)
+	if err != nil {
+		return err
+	}
+
+	var projects []string
+	counts := map[string]int{}
+	for _, w := range workspaces {
+		count, err := countBillableResources(ctx, w, organization, config)
+		if err != nil {
+			return err
+		}
+
+		project := "na"
+		if w.Project != nil {
+			project = w.Project.Name
+		}
+		if _, ok := counts[project]; !ok {
+			projects = append(projects, project)
+		}
+		counts[project] += count
+	}
+
+	for _, project := range projects {
+		count := counts[project]
+		peak := s.peaks.observe(billableResourcesKey{organization: organization, project: project}, count)
+		for _, m := range []prometheus.Metric{
+			prometheus.MustNewConstMetric(BillableResources, prometheus.GaugeValue, float64(count), organization, project),
+			prometheus.MustNewConstMetric(BillableResourcesHourlyPeak, prometheus.GaugeValue, float64(peak), organization, project),
+		} {
+			select {
+			case ch <- m:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (s ScrapeBillableResources) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			return s.getBillableResources(ctx, name, config, ch)
+		})
+	}
+
+	return g.Wait()
+}
+
+// isBillableResource follows HashiCorp's resources under management definition.
+func isBillableResource(r *tfe.WorkspaceResource) bool {
+	if isDataSource(r.Address) {
+		return false
+	}
+
+	return !nonBillableResourceTypes[r.ProviderType]
+}
+
+// isDataSource tells whether an address is the one of a data source, "data.<type>.<name>" once the
+// module path ("module.<name>[<index>]." repeated) is removed. A module can itself be named data.
+func isDataSource(address string) bool {
+	for strings.HasPrefix(address, "module.") {
+		address = address[len("module."):]
+		end := moduleCallEnd(address)
+		if end < 0 {
+			return false
+		}
+		address = address[end+1:]
+	}
+
+	return strings.HasPrefix(address, "data.")
+}
+
+// moduleCallEnd returns the position of the dot ending a module name and its index, skipping the dots
+// of quoted keys, or -1 if there is none.
+func moduleCallEnd(address string) int {
+	quoted := false
+	for i := 0; i < len(address); i++ {
+		switch address[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case '.':
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
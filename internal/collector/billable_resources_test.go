@@ -0,0 +1,139 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeBillableResources(t *testing.T) {
+	now = func() time.Time { return time.Date(2020, 10, 10, 10, 10, 10, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/test-org/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":2}
+			},
+			"data":[{
+				"id":"ws-prod",
+				"type":"workspaces",
+				"attributes":{"name":"prod"},
+				"relationships":{"project":{"data":{"id":"prj-1","type":"projects"}}}
+			}, {
+				"id":"ws-dev",
+				"type":"workspaces",
+				"attributes":{"name":"dev"},
+				"relationships":{"project":{"data":{"id":"prj-1","type":"projects"}}}
+			}],
+			"included":[{"id":"prj-1","type":"projects","attributes":{"name":"platform"}}]
+		}`))
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-prod/resources", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":4}
+			},
+			"data":[
+				{"id":"wsr-1","type":"resources","attributes":{"address":"aws_instance.web","provider-type":"aws_instance"}},
+				{"id":"wsr-2","type":"resources","attributes":{"address":"module.vpc.aws_vpc.this","provider-type":"aws_vpc"}},
+				{"id":"wsr-3","type":"resources","attributes":{"address":"null_resource.hook","provider-type":"null_resource"}},
+				{"id":"wsr-4","type":"resources","attributes":{"address":"module.vpc.data.aws_region.current","provider-type":"aws_region"}}
+			]
+		}`))
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-dev/resources", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":1}
+			},
+			"data":[
+				{"id":"wsr-5","type":"resources","attributes":{"address":"aws_instance.web","provider-type":"aws_instance"}}
+			]
+		}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+	}
+
+	scraper := ScrapeBillableResources{peaks: &billablePeaks{
+		hour:  now().Truncate(time.Hour),
+		peaks: map[billableResourcesKey]int{{organization: "test-org", project: "platform"}: 5},
+	}}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		if err = scraper.Scrape(context.Background(), config, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+	}()
+
+	labels := labelMap{"organization": "test-org", "project": "platform"}
+	counterExpected := []MetricResult{
+		{labels: labels, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 5, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range counterExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+}
+
+func TestIsBillableResource(t *testing.T) {
+	tests := []struct {
+		address      string
+		providerType string
+		billable     bool
+	}{
+		{"aws_instance.web", "aws_instance", true},
+		{"data.aws_region.current", "aws_region", false},
+		{"module.vpc.data.aws_region.current", "aws_region", false},
+		{"module.vpc.module.subnets.data.aws_region.current", "aws_region", false},
+		{"module.data.aws_s3_bucket.logs", "aws_s3_bucket", true},
+		{"module.data.module.data.aws_s3_bucket.logs", "aws_s3_bucket", true},
+		{"module.data.data.aws_region.current", "aws_region", false},
+		{`module.buckets["data.logs"].aws_s3_bucket.this`, "aws_s3_bucket", true},
+		{`module.buckets["a.b"].data.aws_region.current`, "aws_region", false},
+		{"null_resource.hook", "null_resource", false},
+		{"module.data.terraform_data.hook", "terraform_data", false},
+	}
+
+	convey.Convey("Data sources and free resources aren't billable", t, func() {
+		for _, test := range tests {
+			r := &tfe.WorkspaceResource{Address: test.address, ProviderType: test.providerType}
+			convey.So(isBillableResource(r), convey.ShouldEqual, test.billable)
+		}
+	})
+}
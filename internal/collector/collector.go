@@ -10,8 +10,6 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
 
-	tfe "github.com/hashicorp/go-tfe"
-
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -22,20 +20,48 @@ const (
 	exporter = "exporter"
 )
 
-// Exporter collects TF metrics. It implements the prometheus.Collector interface.
+// Exporter collects TF metrics in the background and serves the results of the last collection.
+// It implements the prometheus.Collector interface.
 type Exporter struct {
-	ctx      context.Context
 	logger   log.Logger
 	config   setup.Config
 	scrapers []Scraper
 	metrics  Metrics
+
+	// discovery holds the organizations discovered when none are configured.
+	discovery *discovery
+
+	// all are the scrapers with the state of this Exporter, enabled or not.
+	all []Scraper
+
+	// mtx guards the config, logger and scrapers, which change on reload, and the results.
+	mtx     sync.RWMutex
+	results map[string]*scrapeResult
+	// started is when each scraper last started a successful run, to skip it until its interval elapsed.
+	started map[string]time.Time
+	// running are the scrapers with a run in progress, which aren't started again meanwhile.
+	running map[string]bool
+	// failed are the scrapers whose last run failed.
+	failed map[string]bool
+	// background tracks the runs of the scrapers with a longer interval, which collections don't wait for.
+	background sync.WaitGroup
 }
 
-// Metrics represents exporter metrics which values can be carried between http requests.
+// Metrics represents exporter metrics which values can be carried between collections.
 type Metrics struct {
 	TotalScrapes prometheus.Counter
 	ScrapeErrors *prometheus.CounterVec
 	Error        prometheus.Gauge
+	// DiscoveredOrganizations is the number of organizations scraped when none are configured.
+	DiscoveredOrganizations prometheus.Gauge
+}
+
+// scrapeResult holds the metrics of the last successful run of a scraper.
+type scrapeResult struct {
+	metrics []prometheus.Metric
+	// duration is the one of the last run, even if it failed.
+	duration  time.Duration
+	timestamp time.Time
 }
 
 var (
@@ -47,16 +73,29 @@ var (
 		"Collector time duration.",
 		[]string{"collector"}, nil,
 	)
+	lastCollectionDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, exporter, "last_collection_timestamp_seconds"),
+		"Unix timestamp of the last successful collection of the collector.",
+		[]string{"collector"}, nil,
+	)
+	// now is overridden in tests to get predictable durations.
+	now = time.Now
 )
 
 // New returns a new Terraform API exporter for the provided Config.
-func New(ctx context.Context, config setup.Config, metrics Metrics) *Exporter {
+func New(config setup.Config, metrics Metrics) *Exporter {
+	all := newScrapers()
 	return &Exporter{
-		ctx:      ctx,
-		logger:   config.Logger,
-		config:   config,
-		scrapers: Scrapers,
-		metrics:  metrics,
+		logger:    config.Logger,
+		config:    config,
+		scrapers:  enabledScrapers(all, config),
+		metrics:   metrics,
+		discovery: &discovery{},
+		all:       all,
+		results:   map[string]*scrapeResult{},
+		started:   map[string]time.Time{},
+		running:   map[string]bool{},
+		failed:    map[string]bool{},
 	}
 }
 
@@ -64,51 +103,253 @@ func New(ctx context.Context, config setup.Config, metrics Metrics) *Exporter {
 func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.metrics.TotalScrapes.Desc()
 	ch <- e.metrics.Error.Desc()
+	ch <- e.metrics.DiscoveredOrganizations.Desc()
 	e.metrics.ScrapeErrors.Describe(ch)
 }
 
-// Collect implements the prometheus.Collector interface.
+// Collect implements the prometheus.Collector interface, it only serves the cached results.
 func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
-	e.scrape(e.ctx, ch)
+	e.collect(ch, Filter{})
+}
+
+func (e *Exporter) collect(ch chan<- prometheus.Metric, f Filter) {
+	e.mtx.RLock()
+	for _, scraper := range e.scrapers {
+		result, ok := e.results[scraper.Name()]
+		if !ok || !f.matchCollector(scraper.Name()) {
+			continue
+		}
+
+		label := "collect." + scraper.Name()
+		for _, m := range result.metrics {
+			if f.matchMetric(m) {
+				ch <- m
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, result.duration.Seconds(), label)
+		if !result.timestamp.IsZero() {
+			ch <- prometheus.MustNewConstMetric(lastCollectionDesc, prometheus.GaugeValue, float64(result.timestamp.UnixNano())/1e9, label)
+		}
+	}
+	discovered := len(e.config.Organizations) == 0
+	e.mtx.RUnlock()
 
 	ch <- e.metrics.TotalScrapes
 	ch <- e.metrics.Error
+	if discovered {
+		ch <- e.metrics.DiscoveredOrganizations
+	}
 	e.metrics.ScrapeErrors.Collect(ch)
 }
 
-func (e *Exporter) scrape(ctx context.Context, ch chan<- prometheus.Metric) {
+// Run collects the metrics every collection interval until the context is canceled.
+func (e *Exporter) Run(ctx context.Context) {
+	defer e.background.Wait()
+	for {
+		// Read it every time, as it can change on reload.
+		timer := time.NewTimer(e.Config().CollectionInterval)
+
+		e.scrape(ctx)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// Config returns the Config currently in use.
+func (e *Exporter) Config() setup.Config {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	return e.config
+}
+
+// SetConfig replaces the Config, from the next collection on. The cached results are kept.
+func (e *Exporter) SetConfig(config setup.Config) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.config = config
+	e.logger = config.Logger
+	e.scrapers = enabledScrapers(e.all, config)
+	// The organizations or their filters may have changed.
+	e.discovery = &discovery{}
+}
+
+// scrape runs the scrapers which are due. A run can't last longer than the interval of its scraper, so that
+// the runs of a scraper never overlap. The collection waits for the scrapers running every collection interval,
+// the ones with a longer interval run in the background.
+func (e *Exporter) scrape(ctx context.Context) {
 	e.metrics.TotalScrapes.Inc()
-	if len(e.config.Organizations) == 0 {
-		// Note: At some point this will return a paginated response.
-		oo, err := e.config.Client.Organizations.List(ctx, &tfe.OrganizationListOptions{})
-		if err != nil {
-			e.metrics.Error.Set(1)
-			level.Error(e.logger).Log("msg", "Unable to List Organizations", "err", err)
+
+	e.mtx.RLock()
+	// Work on copies, the config can be replaced on reload during the collection.
+	config, logger, scrapers, discovery := e.config, e.logger, e.scrapers, e.discovery
+	e.mtx.RUnlock()
+
+	// The error gauge is only set once the collection is over, to keep reporting the previous one meanwhile.
+	discoveryFailed := false
+	defer func() { e.setError(discoveryFailed) }()
+
+	discovered := len(config.Organizations) == 0
+	discoveryCtx, cancel := withInterval(ctx, config.CollectionInterval)
+	organizations, err := discovery.Organizations(discoveryCtx, &config)
+	cancel()
+	if err != nil {
+		discoveryFailed = true
+		if len(organizations) == 0 {
+			level.Error(logger).Log("msg", "Unable to discover organizations", "err", err)
 			return
 		}
+		level.Warn(logger).Log("msg", "Unable to refresh organizations, using the previously discovered ones", "err", err)
+	}
+	config.Organizations = organizations
+	if discovered {
+		e.metrics.DiscoveredOrganizations.Set(float64(len(organizations)))
+	}
 
-		for _, o := range oo.Items {
-			e.config.Organizations = append(e.config.Organizations, o.Name)
+	var due []Scraper
+	configs := map[string]*setup.Config{}
+	var longest time.Duration
+	for _, scraper := range scrapers {
+		// Only pass the organizations the scraper is enabled for.
+		scraperConfig := config
+		scraperConfig.Organizations = nil
+		for _, name := range config.Organizations {
+			if config.CollectorEnabled(scraper.Name(), name) {
+				scraperConfig.Organizations = append(scraperConfig.Organizations, name)
+			}
 		}
+		if len(scraperConfig.Organizations) == 0 || !e.start(scraper, config) {
+			continue
+		}
+		due = append(due, scraper)
+		configs[scraper.Name()] = &scraperConfig
+		if interval := config.CollectorInterval(scraper.Name()); interval > longest {
+			longest = interval
+		}
+	}
+	if len(due) == 0 {
+		return
 	}
 
-	e.metrics.Error.Set(0)
+	// The scrapers share the workspaces listed during this collection, until the last of them is over.
+	inventoryCtx, cancelInventory := withInterval(ctx, longest)
+	inventory := newInventory(inventoryCtx, &config)
+	var runs, wg sync.WaitGroup
+	runs.Add(len(due))
+	go func() {
+		runs.Wait()
+		cancelInventory()
+	}()
 
-	var wg sync.WaitGroup
 	defer wg.Wait()
-	for _, scraper := range e.scrapers {
-		wg.Add(1)
-		go func(scraper Scraper) {
-			defer wg.Done()
+	for _, scraper := range due {
+		interval := config.CollectorInterval(scraper.Name())
+		group := &wg
+		if interval > config.CollectionInterval {
+			group = &e.background
+		}
+
+		group.Add(1)
+		go func(scraper Scraper, scraperConfig *setup.Config, group *sync.WaitGroup) {
+			defer group.Done()
+			defer runs.Done()
 			label := "collect." + scraper.Name()
-			scrapeTime := time.Now()
-			if err := scraper.Scrape(ctx, &e.config, ch); err != nil {
-				level.Error(e.logger).Log("msg", "Error from scraper", "scraper", scraper.Name(), "err", err)
+			startTime, scrapeTime := now(), time.Now()
+
+			scrapeCtx, cancel := withInterval(withInventory(ctx, inventory), interval)
+			defer cancel()
+
+			ch := make(chan prometheus.Metric)
+			done := make(chan struct{})
+			var metrics []prometheus.Metric
+			go func() {
+				defer close(done)
+				for m := range ch {
+					metrics = append(metrics, m)
+				}
+			}()
+
+			err := scraper.Scrape(scrapeCtx, scraperConfig, ch)
+			close(ch)
+			<-done
+			if err != nil {
+				level.Error(logger).Log("msg", "Error from scraper", "scraper", scraper.Name(), "err", err)
 				e.metrics.ScrapeErrors.WithLabelValues(label).Inc()
-				e.metrics.Error.Set(1)
 			}
-			ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(scrapeTime).Seconds(), label)
-		}(scraper)
+			e.store(scraper, metrics, startTime, time.Since(scrapeTime), err)
+		}(scraper, configs[scraper.Name()], group)
+	}
+}
+
+// withInterval bounds a context to an interval, a zero interval doesn't.
+func withInterval(ctx context.Context, interval time.Duration) (context.Context, context.CancelFunc) {
+	if interval <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, interval)
+}
+
+// start tells whether a scraper is due for this collection, recording that it runs if so. The collections
+// happen every collection interval, scrapers with a longer interval are skipped until it elapsed since
+// their last successful run.
+func (e *Exporter) start(scraper Scraper, config setup.Config) bool {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if e.running[scraper.Name()] {
+		return false
+	}
+
+	// Collections don't start exactly one interval apart, allow for half of it.
+	started, ok := e.started[scraper.Name()]
+	if ok && now().Sub(started)+config.CollectionInterval/2 < config.CollectorInterval(scraper.Name()) {
+		return false
+	}
+
+	e.running[scraper.Name()] = true
+	return true
+}
+
+// setError sets the error gauge from the last run of every scraper.
+func (e *Exporter) setError(failed bool) {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+
+	for _, f := range e.failed {
+		failed = failed || f
+	}
+	if failed {
+		e.metrics.Error.Set(1)
+	} else {
+		e.metrics.Error.Set(0)
+	}
+}
+
+// store caches the results of a scraper run. A failed run keeps serving the previous metrics,
+// its last collection timestamp shows how stale they are, and is retried on the next collection.
+func (e *Exporter) store(scraper Scraper, metrics []prometheus.Metric, started time.Time, duration time.Duration, err error) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	delete(e.running, scraper.Name())
+	e.failed[scraper.Name()] = err != nil
+	if err == nil {
+		e.started[scraper.Name()] = started
+	}
+
+	result, ok := e.results[scraper.Name()]
+	if !ok {
+		result = &scrapeResult{}
+		e.results[scraper.Name()] = result
+	}
+	result.duration = duration
+	if err == nil {
+		result.metrics = metrics
+		result.timestamp = now()
 	}
 }
 
@@ -133,5 +374,11 @@ func NewMetrics() Metrics {
 			Name:      "last_scrape_error",
 			Help:      "Whether the last scrape of metrics from Terraform API resulted in an error (1 for error, 0 for success).",
 		}),
+		DiscoveredOrganizations: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: exporter,
+			Name:      "discovered_organizations",
+			Help:      "Number of organizations discovered and scraped when none are configured.",
+		}),
 	}
 }
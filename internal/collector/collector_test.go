@@ -1,8 +1,18 @@
 package collector
 
 import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
 )
 
 type labelMap map[string]string
@@ -31,3 +41,262 @@ func readMetric(m prometheus.Metric) MetricResult {
 	}
 	panic("Unsupported metric type")
 }
+
+var stubDesc = prometheus.NewDesc("tf_stub", "Stub metric.", []string{"organization"}, nil)
+
+// stubScraper sends a gauge per organization, or fails when err is set.
+type stubScraper struct {
+	value *float64
+	err   *error
+}
+
+func (stubScraper) Name() string    { return "stub" }
+func (stubScraper) Help() string    { return "Stub scraper." }
+func (stubScraper) Version() string { return "v2" }
+
+func (s stubScraper) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	if *s.err != nil {
+		return *s.err
+	}
+	for _, name := range config.Organizations {
+		ch <- prometheus.MustNewConstMetric(stubDesc, prometheus.GaugeValue, *s.value, name)
+	}
+	return nil
+}
+
+func TestExporterCache(t *testing.T) {
+	now = func() time.Time { return time.Date(2020, 10, 10, 10, 10, 10, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	value, err := 1.0, error(nil)
+	e := New(setup.Config{
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+		Logger: log.NewNopLogger(),
+	}, NewMetrics())
+	e.scrapers = []Scraper{stubScraper{value: &value, err: &err}}
+
+	collect := func() []prometheus.Metric {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			e.Collect(ch)
+		}()
+		var metrics []prometheus.Metric
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		return metrics
+	}
+
+	convey.Convey("Nothing is served before the first collection", t, func() {
+		metrics := collect()
+		convey.So(metrics, convey.ShouldHaveLength, 2)
+		convey.So(readMetric(metrics[0]), convey.ShouldResemble, MetricResult{labels: labelMap{}, value: 0, metricType: dto.MetricType_COUNTER})
+	})
+
+	e.scrape(context.Background())
+	value, err = 2, errors.New("API unavailable")
+	now = func() time.Time { return time.Date(2020, 10, 10, 10, 20, 10, 0, time.UTC) }
+	e.scrape(context.Background())
+
+	convey.Convey("A failed collection keeps serving the last results", t, func() {
+		metrics := collect()
+		convey.So(metrics, convey.ShouldHaveLength, 6)
+		convey.So(readMetric(metrics[0]), convey.ShouldResemble, MetricResult{labels: labelMap{"organization": "test-org"}, value: 1, metricType: dto.MetricType_GAUGE})
+		convey.So(readMetric(metrics[2]), convey.ShouldResemble, MetricResult{labels: labelMap{"collector": "collect.stub"}, value: 1602324610, metricType: dto.MetricType_GAUGE})
+		convey.So(readMetric(metrics[3]), convey.ShouldResemble, MetricResult{labels: labelMap{}, value: 2, metricType: dto.MetricType_COUNTER})
+		convey.So(readMetric(metrics[4]), convey.ShouldResemble, MetricResult{labels: labelMap{}, value: 1, metricType: dto.MetricType_GAUGE})
+	})
+}
+
+func TestEnabledScrapers(t *testing.T) {
+	convey.Convey("All scrapers are enabled when the config doesn't tell", t, func() {
+		convey.So(enabledScrapers(Scrapers, setup.Config{}), convey.ShouldHaveLength, len(Scrapers))
+	})
+
+	convey.Convey("Only the enabled scrapers are kept", t, func() {
+		scrapers := enabledScrapers(Scrapers, setup.Config{Collectors: map[string]bool{
+			organizationsSubsystem: true,
+			workspacesSubsystem:    false,
+		}})
+		convey.So(scrapers, convey.ShouldResemble, []Scraper{ScrapeOrganizations{}})
+	})
+
+	convey.Convey("Expensive scrapers are opt-in", t, func() {
+		for _, c := range Collectors() {
+			convey.So(c.EnabledDefault, convey.ShouldEqual, !optInScrapers[c.Name])
+		}
+	})
+}
+
+func TestExporterOrganizationSettings(t *testing.T) {
+	value, err := 1.0, error(nil)
+	e := New(setup.Config{
+		CLI:        setup.CLI{Organizations: []string{"org-a", "org-b"}},
+		Collectors: map[string]bool{"stub": false},
+		OrganizationSettings: map[string]setup.OrganizationSettings{
+			"org-b": {Collectors: map[string]bool{"stub": true}},
+		},
+		Logger: log.NewNopLogger(),
+	}, NewMetrics())
+	e.scrapers = []Scraper{stubScraper{value: &value, err: &err}}
+	e.scrape(context.Background())
+
+	convey.Convey("Collectors only run for the organizations they are enabled for", t, func() {
+		metrics := e.results["stub"].metrics
+		convey.So(metrics, convey.ShouldHaveLength, 1)
+		convey.So(readMetric(metrics[0]), convey.ShouldResemble, MetricResult{labels: labelMap{"organization": "org-b"}, value: 1, metricType: dto.MetricType_GAUGE})
+	})
+}
+
+func TestExporterCollectorIntervals(t *testing.T) {
+	now = func() time.Time { return time.Date(2020, 10, 10, 10, 10, 10, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	value, err := 1.0, error(nil)
+	e := New(setup.Config{
+		CLI: setup.CLI{
+			Organizations:      []string{"test-org"},
+			CollectionInterval: 5 * time.Minute,
+			CollectorIntervals: map[string]time.Duration{"stub": time.Hour},
+		},
+		Logger: log.NewNopLogger(),
+	}, NewMetrics())
+	e.scrapers = []Scraper{stubScraper{value: &value, err: &err}}
+
+	served := func() float64 {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			e.Collect(ch)
+		}()
+		first := readMetric(<-ch)
+		for range ch {
+		}
+		return first.value
+	}
+
+	// The collector has a longer interval, it runs in the background.
+	scrape := func() {
+		e.scrape(context.Background())
+		e.background.Wait()
+	}
+	scrape()
+	value = 2
+
+	convey.Convey("A collector is skipped until its interval elapsed", t, func() {
+		now = func() time.Time { return time.Date(2020, 10, 10, 10, 15, 10, 0, time.UTC) }
+		scrape()
+		convey.So(served(), convey.ShouldEqual, 1)
+
+		// Collections don't start exactly one interval apart.
+		now = func() time.Time { return time.Date(2020, 10, 10, 11, 10, 9, 0, time.UTC) }
+		scrape()
+		convey.So(served(), convey.ShouldEqual, 2)
+	})
+
+	err = errors.New("API unavailable")
+	value = 3
+	now = func() time.Time { return time.Date(2020, 10, 10, 12, 10, 9, 0, time.UTC) }
+	scrape()
+	err = nil
+
+	convey.Convey("A failed run is retried on the next collection", t, func() {
+		now = func() time.Time { return time.Date(2020, 10, 10, 12, 15, 9, 0, time.UTC) }
+		scrape()
+		convey.So(served(), convey.ShouldEqual, 3)
+	})
+}
+
+func TestExporterBackgroundCollectors(t *testing.T) {
+	e := New(setup.Config{
+		CLI: setup.CLI{
+			Organizations:      []string{"test-org"},
+			CollectionInterval: time.Minute,
+			CollectorIntervals: map[string]time.Duration{"blocking": time.Hour},
+		},
+		Logger: log.NewNopLogger(),
+	}, NewMetrics())
+	scraper := blockingScraper{started: make(chan struct{}), release: make(chan struct{}), deadline: make(chan time.Time, 1)}
+	e.scrapers = []Scraper{scraper}
+	e.scrape(context.Background())
+	<-scraper.started
+
+	convey.Convey("A collector with a longer interval doesn't block the collection", t, func() {
+		convey.So(e.start(scraper, e.Config()), convey.ShouldBeFalse)
+	})
+
+	deadline := <-scraper.deadline
+	scraper.release <- struct{}{}
+	e.background.Wait()
+
+	convey.Convey("A collector run lasts at most its interval", t, func() {
+		convey.So(time.Until(deadline), convey.ShouldBeGreaterThan, time.Minute)
+		convey.So(time.Until(deadline), convey.ShouldBeLessThanOrEqualTo, time.Hour)
+	})
+}
+
+// blockingScraper runs until it is released, failing with err. It reports the deadline of its context
+// to the deadline channel, if any.
+type blockingScraper struct {
+	started, release chan struct{}
+	deadline         chan time.Time
+	err              error
+}
+
+func (blockingScraper) Name() string    { return "blocking" }
+func (blockingScraper) Help() string    { return "Blocking scraper." }
+func (blockingScraper) Version() string { return "v2" }
+
+func (s blockingScraper) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	s.started <- struct{}{}
+	if s.deadline != nil {
+		deadline, _ := ctx.Deadline()
+		s.deadline <- deadline
+	}
+	<-s.release
+	return s.err
+}
+
+func TestExporterErrorGauge(t *testing.T) {
+	e := New(setup.Config{
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+		Logger: log.NewNopLogger(),
+	}, NewMetrics())
+
+	errorValue := func() float64 {
+		return readMetric(e.metrics.Error).value
+	}
+
+	scraper := blockingScraper{started: make(chan struct{}), release: make(chan struct{}), err: errors.New("API unavailable")}
+	e.scrapers = []Scraper{scraper}
+	go func() {
+		<-scraper.started
+		scraper.release <- struct{}{}
+	}()
+	e.scrape(context.Background())
+
+	convey.Convey("The error gauge tells whether the last collection failed", t, func() {
+		convey.So(errorValue(), convey.ShouldEqual, 1)
+	})
+
+	scraper.err = nil
+	e.scrapers = []Scraper{scraper}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.scrape(context.Background())
+	}()
+	<-scraper.started
+
+	convey.Convey("The error gauge is kept while a collection is in progress", t, func() {
+		convey.So(errorValue(), convey.ShouldEqual, 1)
+	})
+
+	scraper.release <- struct{}{}
+	<-done
+
+	convey.Convey("The error gauge is reset once a collection succeeded", t, func() {
+		convey.So(errorValue(), convey.ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// configurationVersions is the Metric subsystem we use.
+	configurationVersionsSubsystem = "configuration_versions"
+)
+
+// Metric descriptors.
+var (
+	ConfigurationVersionsInfo = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, configurationVersionsSubsystem, "info"),
+		"Information about the latest configuration version of each workspace, including its VCS ingress attributes.",
+		[]string{"organization", "workspace", "id", "status", "source", "branch", "commit_sha", "commit_url", "sender"}, nil,
+	)
+	ConfigurationVersionsUploaded = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, configurationVersionsSubsystem, "uploaded_timestamp_seconds"),
+		"Unix timestamp at which the latest configuration version of each workspace was uploaded.",
+		[]string{"organization", "workspace"}, nil,
+	)
+)
+
+// configurationVersionUpload decodes the upload time of a configuration version, go-tfe doesn't.
+type configurationVersionUpload struct {
+	ID               string `jsonapi:"primary,configuration-versions"`
+	StatusTimestamps *struct {
+		UploadedAt time.Time `jsonapi:"attr,uploaded-at,rfc3339"`
+	} `jsonapi:"attr,status-timestamps"`
+}
+
+// ScrapeConfigurationVersions scrapes the latest configuration version of the workspaces.
+type ScrapeConfigurationVersions struct{}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeConfigurationVersions{})
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeConfigurationVersions) Name() string {
+	return configurationVersionsSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeConfigurationVersions) Help() string {
+	return "Scrape information from the Configuration Versions API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/configuration-versions"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeConfigurationVersions) Version() string {
+	return "v2"
+}
+
+func getLatestConfigurationVersion(ctx context.Context, w *tfe.Workspace, organization string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	// Configuration versions are listed newest first.
+	req, err := config.Client.NewRequest("GET", fmt.Sprintf("workspaces/%s/configuration-versions", url.PathEscape(w.ID)), &tfe.ConfigurationVersionListOptions{
+		ListOptions: tfe.ListOptions{PageSize: 1},
+		Include:     []tfe.ConfigVerIncludeOpt{tfe.ConfigVerIngressAttributes},
+	})
+	if err != nil {
+		return err
+	}
+
+	body := &bytes.Buffer{}
+	if err := req.Do(ctx, body); err != nil {
+		return fmt.Errorf("%v, (organization=%s, workspace=%s)", err, organization, w.Name)
+	}
+	items, err := jsonapi.UnmarshalManyPayload(bytes.NewReader(body.Bytes()), reflect.TypeOf(&tfe.ConfigurationVersion{}))
+	if err != nil {
+		return fmt.Errorf("%v, (organization=%s, workspace=%s)", err, organization, w.Name)
+	}
+	uploads, err := jsonapi.UnmarshalManyPayload(bytes.NewReader(body.Bytes()), reflect.TypeOf(&configurationVersionUpload{}))
+	if err != nil {
+		return fmt.Errorf("%v, (organization=%s, workspace=%s)", err, organization, w.Name)
+	}
+	if len(items) == 0 || len(uploads) == 0 {
+		return nil
+	}
+
+	cv := items[0].(*tfe.ConfigurationVersion)
+	upload := uploads[0].(*configurationVersionUpload)
+	ingress := cv.IngressAttributes
+	if ingress == nil {
+		ingress = &tfe.IngressAttributes{}
+	}
+
+	metrics := []prometheus.Metric{
+		prometheus.MustNewConstMetric(
+			ConfigurationVersionsInfo,
+			prometheus.GaugeValue,
+			1,
+			organization,
+			w.Name,
+			cv.ID,
+			string(cv.Status),
+			string(cv.Source),
+			ingress.Branch,
+			ingress.CommitSHA,
+			ingress.CommitURL,
+			ingress.SenderUsername,
+		),
+	}
+	if upload.StatusTimestamps != nil && !upload.StatusTimestamps.UploadedAt.IsZero() {
+		metrics = append(metrics, prometheus.MustNewConstMetric(
+			ConfigurationVersionsUploaded,
+			prometheus.GaugeValue,
+			float64(upload.StatusTimestamps.UploadedAt.Unix()),
+			organization,
+			w.Name,
+		))
+	}
+
+	for _, m := range metrics {
+		select {
+		case ch <- m:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (ScrapeConfigurationVersions) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			workspaces, err := listWorkspaces(ctx, name, config)
+			if err != nil {
+				return err
+			}
+
+			for _, w := range workspaces {
+				if err := getLatestConfigurationVersion(ctx, w, name, config, ch); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
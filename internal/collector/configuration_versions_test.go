@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeConfigurationVersions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/test-org/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":1}
+			},
+			"data":[{
+				"id":"ws-prod",
+				"type":"workspaces",
+				"attributes":{"name":"prod"}
+			}]
+		}`))
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-prod/configuration-versions", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":2,"total-pages":2,"total-count":2}
+			},
+			"data":[{
+				"id":"cv-1",
+				"type":"configuration-versions",
+				"attributes":{
+					"source":"github",
+					"status":"uploaded",
+					"status-timestamps":{"fetching-at":"2020-10-10T10:09:10Z","uploaded-at":"2020-10-10T10:10:10Z"}
+				},
+				"relationships":{"ingress-attributes":{"data":{"id":"ia-1","type":"ingress-attributes"}}}
+			}],
+			"included":[{
+				"id":"ia-1",
+				"type":"ingress-attributes",
+				"attributes":{
+					"branch":"main",
+					"commit-sha":"abc123",
+					"commit-url":"https://github.com/test/repo/commit/abc123",
+					"sender-username":"octocat"
+				}
+			}]
+		}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		if err = (ScrapeConfigurationVersions{}).Scrape(context.Background(), config, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+	}()
+
+	counterExpected := []MetricResult{
+		{labels: labelMap{"organization": "test-org", "workspace": "prod", "id": "cv-1", "status": "uploaded", "source": "github", "branch": "main", "commit_sha": "abc123", "commit_url": "https://github.com/test/repo/commit/abc123", "sender": "octocat"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "workspace": "prod"}, value: 1602324610, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range counterExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, more := <-ch
+		convey.So(more, convey.ShouldBeFalse)
+	})
+}
@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// currentRun is the Metric subsystem we use.
+	currentRunSubsystem = "current_run"
+)
+
+// Metric descriptors.
+var (
+	CurrentRunResourceAdditions = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, currentRunSubsystem, "resource_additions"),
+		"Number of resources added by the plan or apply of the workspace current run.",
+		[]string{"organization", "workspace", "phase"}, nil,
+	)
+	CurrentRunResourceChanges = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, currentRunSubsystem, "resource_changes"),
+		"Number of resources changed by the plan or apply of the workspace current run.",
+		[]string{"organization", "workspace", "phase"}, nil,
+	)
+	CurrentRunResourceDestructions = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, currentRunSubsystem, "resource_destructions"),
+		"Number of resources destroyed by the plan or apply of the workspace current run.",
+		[]string{"organization", "workspace", "phase"}, nil,
+	)
+	CurrentRunResourceImports = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, currentRunSubsystem, "resource_imports"),
+		"Number of resources imported by the plan or apply of the workspace current run.",
+		[]string{"organization", "workspace", "phase"}, nil,
+	)
+)
+
+// ScrapeCurrentRun scrapes the resource changes of the workspaces current run.
+type ScrapeCurrentRun struct{}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeCurrentRun{})
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeCurrentRun) Name() string {
+	return currentRunSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeCurrentRun) Help() string {
+	return "Scrape information from the Plans and Applies APIs: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/plans"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeCurrentRun) Version() string {
+	return "v2"
+}
+
+func getCurrentRunChanges(ctx context.Context, w *tfe.Workspace, organization string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	r, err := config.Client.Runs.ReadWithOptions(ctx, w.CurrentRun.ID, &tfe.RunReadOptions{
+		Include: []tfe.RunIncludeOpt{tfe.RunPlan, tfe.RunApply},
+	})
+	if err != nil {
+		return fmt.Errorf("%v, (organization=%s, workspace=%s, run=%s)", err, organization, w.Name, w.CurrentRun.ID)
+	}
+
+	var metrics []prometheus.Metric
+	// Counts are only final once the phase finished.
+	if r.Plan != nil && r.Plan.Status == tfe.PlanFinished {
+		metrics = append(metrics, newResourceChangesMetrics("plan", organization, w.Name,
+			r.Plan.ResourceAdditions, r.Plan.ResourceChanges, r.Plan.ResourceDestructions, r.Plan.ResourceImports)...)
+	}
+	if r.Apply != nil && r.Apply.Status == tfe.ApplyFinished {
+		metrics = append(metrics, newResourceChangesMetrics("apply", organization, w.Name,
+			r.Apply.ResourceAdditions, r.Apply.ResourceChanges, r.Apply.ResourceDestructions, r.Apply.ResourceImports)...)
+	}
+
+	for _, m := range metrics {
+		select {
+		case ch <- m:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (ScrapeCurrentRun) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			workspaces, err := listWorkspaces(ctx, name, config)
+			if err != nil {
+				return err
+			}
+
+			for _, w := range workspaces {
+				if w.CurrentRun == nil {
+					continue
+				}
+				if err := getCurrentRunChanges(ctx, w, name, config, ch); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+func newResourceChangesMetrics(phase, organization, workspace string, additions, changes, destructions, imports int) []prometheus.Metric {
+	return []prometheus.Metric{
+		prometheus.MustNewConstMetric(CurrentRunResourceAdditions, prometheus.GaugeValue, float64(additions), organization, workspace, phase),
+		prometheus.MustNewConstMetric(CurrentRunResourceChanges, prometheus.GaugeValue, float64(changes), organization, workspace, phase),
+		prometheus.MustNewConstMetric(CurrentRunResourceDestructions, prometheus.GaugeValue, float64(destructions), organization, workspace, phase),
+		prometheus.MustNewConstMetric(CurrentRunResourceImports, prometheus.GaugeValue, float64(imports), organization, workspace, phase),
+	}
+}
@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeCurrentRun(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/test-org/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":2}
+			},
+			"data":[{
+				"id":"ws-prod",
+				"type":"workspaces",
+				"attributes":{"name":"prod"},
+				"relationships":{"current-run":{"data":{"id":"run-1","type":"runs"}}}
+			}, {
+				"id":"ws-dev",
+				"type":"workspaces",
+				"attributes":{"name":"dev"}
+			}]
+		}`))
+	})
+	mux.HandleFunc("/api/v2/runs/run-1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"data":{
+				"id":"run-1",
+				"type":"runs",
+				"attributes":{"status":"planned"},
+				"relationships":{
+					"plan":{"data":{"id":"plan-1","type":"plans"}},
+					"apply":{"data":{"id":"apply-1","type":"applies"}}
+				}
+			},
+			"included":[
+				{"id":"plan-1","type":"plans","attributes":{"status":"finished","resource-additions":1,"resource-changes":2,"resource-destructions":3,"resource-imports":4}},
+				{"id":"apply-1","type":"applies","attributes":{"status":"unreachable","resource-additions":0,"resource-changes":0,"resource-destructions":0,"resource-imports":0}}
+			]
+		}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		if err = (ScrapeCurrentRun{}).Scrape(context.Background(), config, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+	}()
+
+	labels := labelMap{"organization": "test-org", "workspace": "prod", "phase": "plan"}
+	counterExpected := []MetricResult{
+		{labels: labels, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 4, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range counterExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, more := <-ch
+		convey.So(more, convey.ShouldBeFalse)
+	})
+}
@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+)
+
+// discovery lists the organizations the token can see when none are configured, and keeps them
+// until the organizations refresh interval elapses.
+type discovery struct {
+	mtx           sync.Mutex
+	organizations []string
+	refreshed     time.Time
+}
+
+// Organizations returns the configured organizations, or the discovered ones. When the refresh fails,
+// the previously discovered organizations are returned along with the error.
+func (d *discovery) Organizations(ctx context.Context, config *setup.Config) ([]string, error) {
+	if len(config.Organizations) > 0 {
+		return config.Organizations, nil
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if !d.refreshed.IsZero() && now().Sub(d.refreshed) < config.OrganizationsRefresh {
+		return d.organizations, nil
+	}
+
+	organizations, err := discoverOrganizations(ctx, config)
+	if err != nil {
+		return d.organizations, err
+	}
+	d.organizations, d.refreshed = organizations, now()
+
+	return d.organizations, nil
+}
+
+// discoverOrganizations lists the organizations matching the include and exclude filters, following pagination.
+func discoverOrganizations(ctx context.Context, config *setup.Config) ([]string, error) {
+	include, err := regexp.Compile(config.OrganizationsInclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid organizations include filter: %v", err)
+	}
+	var exclude *regexp.Regexp
+	if config.OrganizationsExclude != "" {
+		if exclude, err = regexp.Compile(config.OrganizationsExclude); err != nil {
+			return nil, fmt.Errorf("invalid organizations exclude filter: %v", err)
+		}
+	}
+
+	var organizations []string
+	for page := 1; ; page++ {
+		oo, err := config.Client.Organizations.List(ctx, &tfe.OrganizationListOptions{
+			ListOptions: tfe.ListOptions{PageSize: config.APIPageSize, PageNumber: page},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%v, (page=%d)", err, page)
+		}
+
+		for _, o := range oo.Items {
+			if !include.MatchString(o.Name) || (exclude != nil && exclude.MatchString(o.Name)) {
+				continue
+			}
+			organizations = append(organizations, o.Name)
+		}
+		if oo.Pagination == nil || page >= oo.Pagination.TotalPages {
+			return organizations, nil
+		}
+	}
+}
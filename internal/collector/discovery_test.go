@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiscovery(t *testing.T) {
+	now = func() time.Time { return time.Date(2020, 10, 10, 10, 10, 10, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	var requests int32
+	pages := map[string]string{
+		"1": `{"id":"prod-a","type":"organizations","attributes":{"name":"prod-a"}},{"id":"dev-a","type":"organizations","attributes":{"name":"dev-a"}}`,
+		"2": `{"id":"prod-b","type":"organizations","attributes":{"name":"prod-b"}},{"id":"prod-sandbox","type":"organizations","attributes":{"name":"prod-sandbox"}}`,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		page := r.URL.Query().Get("page[number]")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(fmt.Sprintf(`{
+			"meta":{
+				"pagination":{"current-page":%s,"total-pages":2,"total-count":4}
+			},
+			"data":[%s]
+		}`, page, pages[page])))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI: setup.CLI{
+			APIPageSize:          2,
+			OrganizationsInclude: "^prod-",
+			OrganizationsExclude: "sandbox",
+			OrganizationsRefresh: time.Hour,
+		},
+	}
+
+	d := &discovery{}
+
+	convey.Convey("Organizations are listed across pages and filtered", t, func() {
+		organizations, err := d.Organizations(context.Background(), config)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(organizations, convey.ShouldResemble, []string{"prod-a", "prod-b"})
+		convey.So(atomic.LoadInt32(&requests), convey.ShouldEqual, 2)
+	})
+
+	convey.Convey("Organizations are only listed again once the refresh interval elapsed", t, func() {
+		d.Organizations(context.Background(), config)
+		convey.So(atomic.LoadInt32(&requests), convey.ShouldEqual, 2)
+
+		now = func() time.Time { return time.Date(2020, 10, 10, 11, 10, 10, 0, time.UTC) }
+		d.Organizations(context.Background(), config)
+		convey.So(atomic.LoadInt32(&requests), convey.ShouldEqual, 4)
+	})
+
+	convey.Convey("Configured organizations aren't discovered", t, func() {
+		configured := *config
+		configured.Organizations = []string{"test-org"}
+		organizations, err := (&discovery{}).Organizations(context.Background(), &configured)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(organizations, convey.ShouldResemble, []string{"test-org"})
+		convey.So(atomic.LoadInt32(&requests), convey.ShouldEqual, 4)
+	})
+}
@@ -0,0 +1,184 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// explorer is the Metric subsystem we use.
+	explorerSubsystem = "explorer"
+)
+
+// Metric descriptors.
+var (
+	ExplorerWorkspaces = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, explorerSubsystem, "workspaces"),
+		"Number of workspaces reported by the explorer.",
+		[]string{"organization"}, nil,
+	)
+	ExplorerTerraformVersionUsage = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, explorerSubsystem, "terraform_version_usage"),
+		"Number of workspaces using each Terraform version.",
+		[]string{"organization", "version"}, nil,
+	)
+	ExplorerProviderUsage = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, explorerSubsystem, "provider_usage"),
+		"Number of workspaces using each provider version.",
+		[]string{"organization", "provider", "source", "version"}, nil,
+	)
+	ExplorerModuleUsage = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, explorerSubsystem, "module_usage"),
+		"Number of workspaces using each module version.",
+		[]string{"organization", "module", "source", "version"}, nil,
+	)
+)
+
+// explorerQueryOptions selects the explorer view to query.
+type explorerQueryOptions struct {
+	tfe.ListOptions
+	Type string `url:"type"`
+}
+
+// explorerRow is a row of the modules, providers or tf_versions explorer views.
+type explorerRow struct {
+	Attributes struct {
+		Name           string `json:"name"`
+		Source         string `json:"source"`
+		Version        string `json:"version"`
+		WorkspaceCount int    `json:"workspace-count"`
+	} `json:"attributes"`
+}
+
+// explorerResponse is the plain JSON response of the explorer, its rows don't have stable JSON:API types.
+type explorerResponse struct {
+	Data []*explorerRow `json:"data"`
+	Meta struct {
+		Pagination *tfe.Pagination `json:"pagination"`
+	} `json:"meta"`
+}
+
+// ScrapeExplorer scrapes the organization wide usage reported by the explorer.
+type ScrapeExplorer struct{}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeExplorer{})
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeExplorer) Name() string {
+	return explorerSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeExplorer) Help() string {
+	return "Scrape information from the Explorer API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/explorer"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeExplorer) Version() string {
+	return "v2"
+}
+
+// queryExplorer returns a page of an explorer view.
+func queryExplorer(ctx context.Context, organization, view string, page, size int, config *setup.Config) (*explorerResponse, error) {
+	req, err := config.Client.NewRequest("GET", fmt.Sprintf("organizations/%s/explorer", url.PathEscape(organization)), &explorerQueryOptions{
+		ListOptions: tfe.ListOptions{PageSize: size, PageNumber: page},
+		Type:        view,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &explorerResponse{}
+	if err := req.DoJSON(ctx, resp); err != nil {
+		return nil, fmt.Errorf("%v, (organization=%s, view=%s, page=%d)", err, organization, view, page)
+	}
+	return resp, nil
+}
+
+// listExplorerView returns every row of an explorer view, following pagination.
+func listExplorerView(ctx context.Context, organization, view string, config *setup.Config) ([]*explorerRow, error) {
+	var rows []*explorerRow
+	for page := 1; ; page++ {
+		resp, err := queryExplorer(ctx, organization, view, page, config.APIPageSize, config)
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, resp.Data...)
+		if resp.Meta.Pagination == nil || page >= resp.Meta.Pagination.TotalPages {
+			return rows, nil
+		}
+	}
+}
+
+func getExplorerUsage(ctx context.Context, organization string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	// We only need the total count of the workspaces view, not its rows.
+	workspaces, err := queryExplorer(ctx, organization, "workspaces", 1, 1, config)
+	if err != nil {
+		return err
+	}
+	workspaceCount := len(workspaces.Data)
+	if workspaces.Meta.Pagination != nil {
+		workspaceCount = workspaces.Meta.Pagination.TotalCount
+	}
+	metrics := []prometheus.Metric{
+		prometheus.MustNewConstMetric(ExplorerWorkspaces, prometheus.GaugeValue, float64(workspaceCount), organization),
+	}
+
+	tfVersions, err := listExplorerView(ctx, organization, "tf_versions", config)
+	if err != nil {
+		return err
+	}
+	for _, r := range tfVersions {
+		metrics = append(metrics, prometheus.MustNewConstMetric(ExplorerTerraformVersionUsage, prometheus.GaugeValue, float64(r.Attributes.WorkspaceCount), organization, r.Attributes.Version))
+	}
+
+	providers, err := listExplorerView(ctx, organization, "providers", config)
+	if err != nil {
+		return err
+	}
+	for _, r := range providers {
+		metrics = append(metrics, prometheus.MustNewConstMetric(ExplorerProviderUsage, prometheus.GaugeValue, float64(r.Attributes.WorkspaceCount), organization, r.Attributes.Name, r.Attributes.Source, r.Attributes.Version))
+	}
+
+	modules, err := listExplorerView(ctx, organization, "modules", config)
+	if err != nil {
+		return err
+	}
+	for _, r := range modules {
+		metrics = append(metrics, prometheus.MustNewConstMetric(ExplorerModuleUsage, prometheus.GaugeValue, float64(r.Attributes.WorkspaceCount), organization, r.Attributes.Name, r.Attributes.Source, r.Attributes.Version))
+	}
+
+	for _, m := range metrics {
+		select {
+		case ch <- m:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (ScrapeExplorer) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			return getExplorerUsage(ctx, name, config, ch)
+		})
+	}
+
+	return g.Wait()
+}
@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeExplorer(t *testing.T) {
+	views := map[string]string{
+		"workspaces": `{
+			"data":[{"id":"ws-1","type":"visibility-workspace","attributes":{"workspace-name":"prod"}}],
+			"meta":{"pagination":{"current-page":1,"prev-page":null,"next-page":2,"total-pages":42,"total-count":42}}
+		}`,
+		"tf_versions": `{
+			"data":[{"type":"visibility-tf-version","attributes":{"version":"1.5.7","workspace-count":30}}],
+			"meta":{"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":1}}
+		}`,
+		"providers": `{
+			"data":[{"type":"visibility-provider-version","attributes":{"name":"aws","source":"hashicorp/aws","version":"5.0.0","workspace-count":12}}],
+			"meta":{"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":1}}
+		}`,
+		"modules": `{
+			"data":[{"type":"visibility-module-version","attributes":{"name":"vpc","source":"app.terraform.io/test-org/vpc/aws","version":"1.2.0","workspace-count":7}}],
+			"meta":{"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":1}}
+		}`,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/test-org/explorer", func(w http.ResponseWriter, r *http.Request) {
+		body, ok := views[r.URL.Query().Get("type")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		if err = (ScrapeExplorer{}).Scrape(context.Background(), config, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+	}()
+
+	counterExpected := []MetricResult{
+		{labels: labelMap{"organization": "test-org"}, value: 42, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "version": "1.5.7"}, value: 30, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "provider": "aws", "source": "hashicorp/aws", "version": "5.0.0"}, value: 12, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "module": "vpc", "source": "app.terraform.io/test-org/vpc/aws", "version": "1.2.0"}, value: 7, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range counterExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+}
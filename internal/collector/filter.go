@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Filter restricts the cached results served for a request, an empty field doesn't restrict anything.
+type Filter struct {
+	// Collectors are the names of the scrapers to serve.
+	Collectors []string
+	// Organizations are the organizations whose metrics are served.
+	Organizations []string
+	// Instances are the names of the instances to serve.
+	Instances []string
+}
+
+// Validate rejects the collector names which don't exist.
+func (f Filter) Validate() error {
+	for _, name := range f.Collectors {
+		known := false
+		for _, c := range Collectors() {
+			known = known || c.Name == name
+		}
+		if !known {
+			return fmt.Errorf("unknown collector %q", name)
+		}
+	}
+	return nil
+}
+
+// organizationLabels names the organization label of the metrics which don't call it "organization".
+var organizationLabels = map[*prometheus.Desc]string{
+	OrganizationsInfo: "name",
+}
+
+func (f Filter) matchCollector(name string) bool {
+	return len(f.Collectors) == 0 || contains(f.Collectors, name)
+}
+
+// matchMetric tells whether a metric belongs to one of the organizations, metrics without organization always do.
+func (f Filter) matchMetric(m prometheus.Metric) bool {
+	if len(f.Organizations) == 0 {
+		return true
+	}
+
+	label, ok := organizationLabels[m.Desc()]
+	if !ok {
+		label = "organization"
+	}
+
+	pb := &dto.Metric{}
+	if err := m.Write(pb); err != nil {
+		return false
+	}
+	for _, l := range pb.Label {
+		if l.GetName() == label {
+			return contains(f.Organizations, l.GetValue())
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// filteredExporter serves the cached results of an Exporter matching a Filter.
+type filteredExporter struct {
+	*Exporter
+	filter Filter
+}
+
+// Filter returns a prometheus.Collector only serving the cached results matching the given Filter.
+func (e *Exporter) Filter(f Filter) prometheus.Collector {
+	return filteredExporter{Exporter: e, filter: f}
+}
+
+// Collect implements the prometheus.Collector interface.
+func (e filteredExporter) Collect(ch chan<- prometheus.Metric) {
+	e.collect(ch, e.filter)
+}
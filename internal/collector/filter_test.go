@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestExporterFilter(t *testing.T) {
+	e := New(setup.Config{Logger: log.NewNopLogger()}, NewMetrics())
+	e.scrapers = []Scraper{ScrapeOrganizations{}, ScrapeWorkspaces{}}
+	e.results = map[string]*scrapeResult{
+		organizationsSubsystem: {metrics: []prometheus.Metric{
+			prometheus.MustNewConstMetric(stubDesc, prometheus.GaugeValue, 1, "org-a"),
+			prometheus.MustNewConstMetric(stubDesc, prometheus.GaugeValue, 2, "org-b"),
+		}},
+		workspacesSubsystem: {metrics: []prometheus.Metric{
+			prometheus.MustNewConstMetric(stubDesc, prometheus.GaugeValue, 3, "org-a"),
+		}},
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		e.Filter(Filter{Collectors: []string{organizationsSubsystem}, Organizations: []string{"org-a"}}).Collect(ch)
+	}()
+
+	counterExpected := []MetricResult{
+		{labels: labelMap{"organization": "org-a"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"collector": "collect.organizations"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{}, value: 0, metricType: dto.MetricType_GAUGE},
+		// No organizations are configured, they are discovered.
+		{labels: labelMap{}, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range counterExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, more := <-ch
+		convey.So(more, convey.ShouldBeFalse)
+	})
+}
+
+func TestFilterOrganizationsInfo(t *testing.T) {
+	info := func(name string) prometheus.Metric {
+		return prometheus.MustNewConstMetric(OrganizationsInfo, prometheus.GaugeValue, 1, name, "", "", "", "", "false", "true")
+	}
+
+	convey.Convey("Organizations info is matched on its name label", t, func() {
+		f := Filter{Organizations: []string{"org-a"}}
+		convey.So(f.matchMetric(info("org-a")), convey.ShouldBeTrue)
+		convey.So(f.matchMetric(info("org-b")), convey.ShouldBeFalse)
+	})
+}
+
+func TestFilterValidate(t *testing.T) {
+	convey.Convey("Only existing collectors can be selected", t, func() {
+		convey.So(Filter{}.Validate(), convey.ShouldBeNil)
+		convey.So(Filter{Collectors: []string{workspacesSubsystem, organizationsSubsystem}}.Validate(), convey.ShouldBeNil)
+		convey.So(Filter{Collectors: []string{workspacesSubsystem, "workspace"}}.Validate(), convey.ShouldNotBeNil)
+	})
+}
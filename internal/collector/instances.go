@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/log/level"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Instances runs an Exporter per Terraform Cloud/Enterprise instance.
+type Instances struct {
+	ctx context.Context
+
+	mtx sync.RWMutex
+	// config is the one of the command line and config file, before splitting it per instance.
+	config    setup.Config
+	names     []string
+	exporters map[string]*Exporter
+	cancels   map[string]context.CancelFunc
+	reloadMtx sync.Mutex
+}
+
+// NewInstances returns the Exporters of the instances configured, running until the context is canceled.
+func NewInstances(ctx context.Context, config setup.Config) (*Instances, error) {
+	i := &Instances{
+		ctx:       ctx,
+		exporters: map[string]*Exporter{},
+		cancels:   map[string]context.CancelFunc{},
+	}
+	if err := i.apply(config); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// apply starts, updates and stops the Exporters to match the config. Updated ones keep their results.
+func (i *Instances) apply(config setup.Config) error {
+	configs, err := config.ForInstances()
+	if err != nil {
+		return err
+	}
+
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	i.config = config
+	names := make([]string, 0, len(configs))
+	seen := map[string]bool{}
+	for _, c := range configs {
+		names = append(names, c.Instance)
+		seen[c.Instance] = true
+
+		if e, ok := i.exporters[c.Instance]; ok {
+			e.SetConfig(c)
+			continue
+		}
+
+		address := c.Client.BaseURL()
+		level.Info(c.Logger).Log("msg", "Starting instance", "instance", c.Instance, "address", address.String())
+		e := New(c, NewMetrics())
+		ctx, cancel := context.WithCancel(i.ctx)
+		go e.Run(ctx)
+		i.exporters[c.Instance] = e
+		i.cancels[c.Instance] = cancel
+	}
+
+	for name, cancel := range i.cancels {
+		if !seen[name] {
+			level.Info(config.Logger).Log("msg", "Stopping instance", "instance", name)
+			cancel()
+			delete(i.exporters, name)
+			delete(i.cancels, name)
+		}
+	}
+	i.names = names
+
+	return nil
+}
+
+// Config returns the Config currently in use, before splitting it per instance.
+func (i *Instances) Config() setup.Config {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+	return i.config
+}
+
+// Exporter returns the Exporter of an instance, or nil if there is none.
+func (i *Instances) Exporter(name string) *Exporter {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+	return i.exporters[name]
+}
+
+// DefaultInstance returns the name of the instance of the flags.
+func (i *Instances) DefaultInstance() string {
+	return i.Config().Instance
+}
+
+// Reload reloads the Config from the command line and the config file, the previous one is kept on errors.
+func (i *Instances) Reload() error {
+	i.reloadMtx.Lock()
+	defer i.reloadMtx.Unlock()
+
+	config, err := i.Config().Reload()
+	if err != nil {
+		return err
+	}
+	return i.apply(config)
+}
+
+// Register registers the results of the instances matching the Filter, adding them an instance label.
+func (i *Instances) Register(registerer prometheus.Registerer, f Filter) error {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+
+	for _, name := range i.names {
+		if len(f.Instances) > 0 && !contains(f.Instances, name) {
+			continue
+		}
+
+		wrapped := prometheus.WrapRegistererWith(prometheus.Labels{"instance": name}, registerer)
+		if err := wrapped.Register(i.exporters[name].Filter(f)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
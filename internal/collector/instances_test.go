@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestInstances(t *testing.T) {
+	mockAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	// Nothing is collected, with every collector disabled and the context canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	instances, err := NewInstances(ctx, setup.Config{
+		CLI: setup.CLI{
+			APIToken:      "test",
+			Instance:      "cloud",
+			Organizations: []string{"test-org"},
+		},
+		Client:     *client,
+		Collectors: map[string]bool{},
+		Instances: map[string]setup.InstanceSettings{
+			"enterprise": {APIAddress: mockAPI.URL, APIToken: "test", Organizations: []string{"test-org"}},
+		},
+		Logger: log.NewNopLogger(),
+	})
+	if err != nil {
+		t.Fatalf("error creating the instances: %s", err)
+	}
+
+	gatherInstances := func(f Filter) []string {
+		registry := prometheus.NewRegistry()
+		if err := instances.Register(registry, f); err != nil {
+			t.Fatalf("error registering the instances: %s", err)
+		}
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("error gathering the instances: %s", err)
+		}
+
+		var names []string
+		for _, family := range families {
+			if family.GetName() != "tf_exporter_scrapes_total" {
+				continue
+			}
+			for _, m := range family.GetMetric() {
+				for _, l := range m.GetLabel() {
+					if l.GetName() == "instance" {
+						names = append(names, l.GetValue())
+					}
+				}
+			}
+		}
+		return names
+	}
+
+	convey.Convey("Every instance gets its own label", t, func() {
+		convey.So(gatherInstances(Filter{}), convey.ShouldResemble, []string{"cloud", "enterprise"})
+	})
+
+	convey.Convey("Instances can be filtered", t, func() {
+		convey.So(gatherInstances(Filter{Instances: []string{"enterprise"}}), convey.ShouldResemble, []string{"enterprise"})
+	})
+
+	convey.Convey("Each instance has its own Exporter", t, func() {
+		convey.So(instances.Exporter("cloud").Config().APIAddress, convey.ShouldEqual, "")
+		convey.So(instances.Exporter("enterprise").Config().APIAddress, convey.ShouldEqual, mockAPI.URL)
+	})
+}
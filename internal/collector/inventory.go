@@ -0,0 +1,178 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+)
+
+// inventoryIncludes are the related resources the scrapers need from the workspaces.
+var inventoryIncludes = []tfe.WSIncludeOpt{tfe.WSProject, tfe.WSLockedBy}
+
+// inventory lists the workspaces of the organizations, with their projects, once per collection
+// and shares them between the scrapers. The first scraper asking for an organization lists it,
+// the other ones wait for it.
+type inventory struct {
+	// ctx is the one of the collection, so that a failing scraper doesn't cancel the listing for the others.
+	ctx    context.Context
+	config *setup.Config
+
+	mtx           sync.Mutex
+	organizations map[string]*organizationInventory
+}
+
+// organizationInventory holds the workspaces of an organization once done is closed.
+type organizationInventory struct {
+	done       chan struct{}
+	workspaces []*tfe.Workspace
+	// latestChanges are the latest change times of the workspaces by ID, go-tfe doesn't decode them.
+	latestChanges map[string]time.Time
+	err           error
+}
+
+// workspaceLatestChange decodes the latest change time of a workspace.
+type workspaceLatestChange struct {
+	ID             string    `jsonapi:"primary,workspaces"`
+	LatestChangeAt time.Time `jsonapi:"attr,latest-change-at,iso8601"`
+}
+
+type inventoryKey struct{}
+
+func newInventory(ctx context.Context, config *setup.Config) *inventory {
+	return &inventory{ctx: ctx, config: config, organizations: map[string]*organizationInventory{}}
+}
+
+// withInventory returns a context handing the inventory to the scrapers.
+func withInventory(ctx context.Context, i *inventory) context.Context {
+	return context.WithValue(ctx, inventoryKey{}, i)
+}
+
+// getInventory returns the inventory of the collection, or a new one when scraping on its own.
+func getInventory(ctx context.Context, config *setup.Config) *inventory {
+	if i, ok := ctx.Value(inventoryKey{}).(*inventory); ok {
+		return i
+	}
+	return newInventory(ctx, config)
+}
+
+// organization returns the inventory of an organization, listing it on first use.
+func (i *inventory) organization(ctx context.Context, name string) (*organizationInventory, error) {
+	i.mtx.Lock()
+	o, ok := i.organizations[name]
+	if !ok {
+		o = &organizationInventory{done: make(chan struct{})}
+		i.organizations[name] = o
+		go func() {
+			defer close(o.done)
+			o.workspaces, o.latestChanges, o.err = i.listWorkspaces(name)
+		}()
+	}
+	i.mtx.Unlock()
+
+	select {
+	case <-o.done:
+		return o, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// listWorkspaces fetches the first page, then the other ones concurrently.
+func (i *inventory) listWorkspaces(organization string) ([]*tfe.Workspace, map[string]time.Time, error) {
+	latestChanges := map[string]time.Time{}
+
+	first, pagination, err := i.getWorkspacesPage(i.ctx, 1, organization, latestChanges)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pagination.TotalPages <= 1 {
+		return first, latestChanges, nil
+	}
+
+	pages := make([][]*tfe.Workspace, pagination.TotalPages)
+	pages[0] = first
+	pageChanges := make([]map[string]time.Time, pagination.TotalPages)
+
+	// A failing page cancels the other ones.
+	g, ctx := errgroup.WithContext(i.ctx)
+	if i.config.WorkspacesPageWorkers > 0 {
+		g.SetLimit(i.config.WorkspacesPageWorkers)
+	}
+	for page := 2; page <= pagination.TotalPages; page++ {
+		page := page
+		g.Go(func() error {
+			pageChanges[page-1] = map[string]time.Time{}
+			workspaces, _, err := i.getWorkspacesPage(ctx, page, organization, pageChanges[page-1])
+			pages[page-1] = workspaces
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	var workspaces []*tfe.Workspace
+	for page, items := range pages {
+		workspaces = append(workspaces, items...)
+		for id, t := range pageChanges[page] {
+			latestChanges[id] = t
+		}
+	}
+	return workspaces, latestChanges, nil
+}
+
+// getWorkspacesPage decodes a page of workspaces both with go-tfe and for their latest change.
+func (i *inventory) getWorkspacesPage(ctx context.Context, page int, organization string, latestChanges map[string]time.Time) ([]*tfe.Workspace, *tfe.Pagination, error) {
+	req, err := i.config.Client.NewRequest("GET", fmt.Sprintf("organizations/%s/workspaces", url.PathEscape(organization)), &tfe.WorkspaceListOptions{
+		ListOptions: tfe.ListOptions{PageSize: i.config.APIPageSize, PageNumber: page},
+		Include:     inventoryIncludes,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body := &bytes.Buffer{}
+	if err := req.Do(ctx, body); err != nil {
+		return nil, nil, fmt.Errorf("%v, (organization=%s, page=%d)", err, organization, page)
+	}
+
+	items, err := jsonapi.UnmarshalManyPayload(bytes.NewReader(body.Bytes()), reflect.TypeOf(&tfe.Workspace{}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v, (organization=%s, page=%d)", err, organization, page)
+	}
+	workspaces := make([]*tfe.Workspace, 0, len(items))
+	for _, item := range items {
+		workspaces = append(workspaces, item.(*tfe.Workspace))
+	}
+
+	changes, err := jsonapi.UnmarshalManyPayload(bytes.NewReader(body.Bytes()), reflect.TypeOf(&workspaceLatestChange{}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v, (organization=%s, page=%d)", err, organization, page)
+	}
+	for _, change := range changes {
+		c := change.(*workspaceLatestChange)
+		latestChanges[c.ID] = c.LatestChangeAt
+	}
+
+	var meta struct {
+		Meta struct {
+			Pagination tfe.Pagination `json:"pagination"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(body.Bytes(), &meta); err != nil {
+		return nil, nil, fmt.Errorf("%v, (organization=%s, page=%d)", err, organization, page)
+	}
+
+	return workspaces, &meta.Meta.Pagination, nil
+}
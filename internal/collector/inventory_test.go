@@ -0,0 +1,138 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestInventory(t *testing.T) {
+	var requests int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/test-org/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":1}
+			},
+			"data":[{
+				"id":"ws-prod",
+				"type":"workspaces",
+				"attributes":{"name":"prod","latest-change-at":"2020-10-10T09:10:10.000Z"},
+				"relationships":{"project":{"data":{"id":"prj-1","type":"projects"}}}
+			}],
+			"included":[{"id":"prj-1","type":"projects","attributes":{"name":"platform"}}]
+		}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+	}
+
+	ctx := withInventory(context.Background(), newInventory(context.Background(), config))
+
+	// Several scrapers asking at once share a single listing.
+	var wg sync.WaitGroup
+	results := make([][]*tfe.Workspace, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = listWorkspaces(ctx, "test-org", config)
+		}(i)
+	}
+	wg.Wait()
+
+	o, err := getInventory(ctx, config).organization(ctx, "test-org")
+
+	convey.Convey("Inventory", t, func() {
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(atomic.LoadInt32(&requests), convey.ShouldEqual, 1)
+		for _, workspaces := range results {
+			convey.So(workspaces, convey.ShouldHaveLength, 1)
+			convey.So(workspaces[0].Name, convey.ShouldEqual, "prod")
+			convey.So(workspaces[0].Project.Name, convey.ShouldEqual, "platform")
+		}
+		convey.So(o.latestChanges["ws-prod"], convey.ShouldEqual, time.Date(2020, 10, 10, 9, 10, 10, 0, time.UTC))
+	})
+}
+
+func TestInventoryPageError(t *testing.T) {
+	canceled := make(chan bool, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/test-org/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page[number]") {
+		case "2":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "3":
+			// Hang until the failure of page 2 cancels the request.
+			select {
+			case <-r.Context().Done():
+				canceled <- true
+			case <-time.After(5 * time.Second):
+				canceled <- false
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"total-pages":3,"total-count":3}
+			},
+			"data":[{"id":"ws-1","type":"workspaces","attributes":{"name":"ws-1"}}]
+		}`))
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI: setup.CLI{
+			Organizations:         []string{"test-org"},
+			APIPageSize:           1,
+			WorkspacesPageWorkers: 2,
+		},
+	}
+
+	_, err = listWorkspaces(context.Background(), "test-org", config)
+
+	convey.Convey("A failing page cancels the other ones", t, func() {
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(<-canceled, convey.ShouldBeTrue)
+	})
+}
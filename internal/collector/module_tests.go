@@ -0,0 +1,167 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// moduleTests is the Metric subsystem we use.
+	moduleTestsSubsystem = "module_tests"
+)
+
+// Metric descriptors.
+var (
+	ModuleTestsInfo = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, moduleTestsSubsystem, "latest_run_info"),
+		"Information about the latest test run of the private registry modules.",
+		[]string{"organization", "module", "provider", "id", "status", "test_status"}, nil,
+	)
+	ModuleTestsPassed = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, moduleTestsSubsystem, "latest_run_passed"),
+		"Whether the latest test run of the module passed (1 for passed, 0 otherwise).",
+		[]string{"organization", "module", "provider"}, nil,
+	)
+	ModuleTestsDuration = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, moduleTestsSubsystem, "latest_run_duration_seconds"),
+		"Duration of the latest completed test run of the module.",
+		[]string{"organization", "module", "provider"}, nil,
+	)
+	ModuleTestsResults = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, moduleTestsSubsystem, "latest_run_tests"),
+		"Number of tests of the latest test run of the module, by result (passed, failed, errored or skipped).",
+		[]string{"organization", "module", "provider", "result"}, nil,
+	)
+)
+
+// ScrapeModuleTests scrapes the latest test run of the private registry modules.
+type ScrapeModuleTests struct{}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeModuleTests{})
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeModuleTests) Name() string {
+	return moduleTestsSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeModuleTests) Help() string {
+	return "Scrape information from the Test Runs API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/tests"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeModuleTests) Version() string {
+	return "v2"
+}
+
+// listRegistryModules returns every private registry module of an organization, following pagination.
+func listRegistryModules(ctx context.Context, organization string, config *setup.Config) ([]*tfe.RegistryModule, error) {
+	var modules []*tfe.RegistryModule
+	for page := 1; ; page++ {
+		moduleList, err := config.Client.RegistryModules.List(ctx, organization, &tfe.RegistryModuleListOptions{
+			ListOptions: tfe.ListOptions{PageSize: config.APIPageSize, PageNumber: page},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%v, (organization=%s, page=%d)", err, organization, page)
+		}
+
+		modules = append(modules, moduleList.Items...)
+		if moduleList.Pagination == nil || page >= moduleList.Pagination.TotalPages {
+			return modules, nil
+		}
+	}
+}
+
+func getLatestTestRun(ctx context.Context, m *tfe.RegistryModule, organization string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	// Test runs are listed newest first.
+	testRuns, err := config.Client.TestRuns.List(ctx, tfe.RegistryModuleID{
+		Organization: organization,
+		Name:         m.Name,
+		Provider:     m.Provider,
+		Namespace:    m.Namespace,
+		RegistryName: m.RegistryName,
+	}, &tfe.TestRunListOptions{
+		ListOptions: tfe.ListOptions{PageSize: 1},
+	})
+	if err != nil {
+		return fmt.Errorf("%v, (organization=%s, module=%s, provider=%s)", err, organization, m.Name, m.Provider)
+	}
+	if len(testRuns.Items) == 0 {
+		return nil
+	}
+
+	tr := testRuns.Items[0]
+	metrics := []prometheus.Metric{
+		prometheus.MustNewConstMetric(ModuleTestsInfo, prometheus.GaugeValue, 1, organization, m.Name, m.Provider, tr.ID, string(tr.Status), string(tr.TestStatus)),
+		prometheus.MustNewConstMetric(ModuleTestsPassed, prometheus.GaugeValue, boolToFloat(tr.TestStatus == tfe.TestPass), organization, m.Name, m.Provider),
+		prometheus.MustNewConstMetric(ModuleTestsResults, prometheus.GaugeValue, float64(tr.TestsPassed), organization, m.Name, m.Provider, "passed"),
+		prometheus.MustNewConstMetric(ModuleTestsResults, prometheus.GaugeValue, float64(tr.TestsFailed), organization, m.Name, m.Provider, "failed"),
+		prometheus.MustNewConstMetric(ModuleTestsResults, prometheus.GaugeValue, float64(tr.TestsErrored), organization, m.Name, m.Provider, "errored"),
+		prometheus.MustNewConstMetric(ModuleTestsResults, prometheus.GaugeValue, float64(tr.TestsSkipped), organization, m.Name, m.Provider, "skipped"),
+	}
+	if d, ok := getTestRunDuration(tr.StatusTimestamps); ok {
+		metrics = append(metrics, prometheus.MustNewConstMetric(ModuleTestsDuration, prometheus.GaugeValue, d.Seconds(), organization, m.Name, m.Provider))
+	}
+
+	for _, metric := range metrics {
+		select {
+		case ch <- metric:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (ScrapeModuleTests) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			modules, err := listRegistryModules(ctx, name, config)
+			if err != nil {
+				return err
+			}
+
+			for _, m := range modules {
+				if m.RegistryName != tfe.PrivateRegistry || m.TestConfig == nil || !m.TestConfig.TestsEnabled {
+					continue
+				}
+				if err := getLatestTestRun(ctx, m, name, config, ch); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+// getTestRunDuration returns how long a completed test run took, reporting false while it is still going.
+func getTestRunDuration(t tfe.TestRunStatusTimestamps) (time.Duration, bool) {
+	if t.StartedAt.IsZero() {
+		return 0, false
+	}
+
+	for _, end := range []time.Time{t.FinishedAt, t.ErroredAt, t.CanceledAt, t.ForceCanceledAt} {
+		if !end.IsZero() {
+			return end.Sub(t.StartedAt), true
+		}
+	}
+
+	return 0, false
+}
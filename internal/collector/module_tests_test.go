@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeModuleTests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/test-org/registry-modules", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":2}
+			},
+			"data":[{
+				"id":"mod-1",
+				"type":"registry-modules",
+				"attributes":{"name":"vpc","provider":"aws","namespace":"test-org","registry-name":"private","test-config":{"tests-enabled":true}}
+			}, {
+				"id":"mod-2",
+				"type":"registry-modules",
+				"attributes":{"name":"dns","provider":"aws","namespace":"test-org","registry-name":"private","test-config":{"tests-enabled":false}}
+			}]
+		}`))
+	})
+	mux.HandleFunc("/api/v2/organizations/test-org/tests/registry-modules/private/test-org/vpc/aws/test-runs", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":1}
+			},
+			"data":[{
+				"id":"trun-1",
+				"type":"test-runs",
+				"attributes":{
+					"status":"finished",
+					"test-status":"fail",
+					"tests-passed":3,
+					"tests-failed":1,
+					"tests-errored":0,
+					"tests-skipped":2,
+					"status-timestamps":{"started-at":"2020-10-10T10:10:10Z","finished-at":"2020-10-10T10:11:40Z"}
+				}
+			}]
+		}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		if err = (ScrapeModuleTests{}).Scrape(context.Background(), config, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+	}()
+
+	module := labelMap{"organization": "test-org", "module": "vpc", "provider": "aws"}
+	counterExpected := []MetricResult{
+		{labels: labelMap{"organization": "test-org", "module": "vpc", "provider": "aws", "id": "trun-1", "status": "finished", "test_status": "fail"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: module, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "module": "vpc", "provider": "aws", "result": "passed"}, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "module": "vpc", "provider": "aws", "result": "failed"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "module": "vpc", "provider": "aws", "result": "errored"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "module": "vpc", "provider": "aws", "result": "skipped"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: module, value: 90, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range counterExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, more := <-ch
+		convey.So(more, convey.ShouldBeFalse)
+	})
+}
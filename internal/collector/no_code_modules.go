@@ -0,0 +1,216 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	version "github.com/hashicorp/go-version"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// noCodeModules is the Metric subsystem we use.
+	noCodeModulesSubsystem = "no_code_modules"
+)
+
+// Metric descriptors.
+var (
+	NoCodeModulesInfo = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, noCodeModulesSubsystem, "info"),
+		"Information about the registry modules enabled for no-code provisioning.",
+		[]string{"organization", "module", "provider", "id", "enabled", "version_pin", "latest_version"}, nil,
+	)
+	NoCodeModulesPinnedLatest = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, noCodeModulesSubsystem, "pinned_latest"),
+		"Whether the no-code module is pinned to the latest published version of the registry module (1 for true, 0 for false).",
+		[]string{"organization", "module", "provider"}, nil,
+	)
+	NoCodeModulesWorkspaces = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, noCodeModulesSubsystem, "workspaces"),
+		"Number of workspaces provisioned from the no-code module, identified by their source URL.",
+		[]string{"organization", "module", "provider"}, nil,
+	)
+)
+
+// noCodeRegistryModule is a tfe.RegistryModule including its no-code modules, which go-tfe doesn't decode.
+type noCodeRegistryModule struct {
+	ID              string                              `jsonapi:"primary,registry-modules"`
+	Name            string                              `jsonapi:"attr,name"`
+	Provider        string                              `jsonapi:"attr,provider"`
+	Namespace       string                              `jsonapi:"attr,namespace"`
+	NoCode          bool                                `jsonapi:"attr,no-code"`
+	VersionStatuses []tfe.RegistryModuleVersionStatuses `jsonapi:"attr,version-statuses"`
+
+	// Relations
+	NoCodeModules []*tfe.RegistryNoCodeModule `jsonapi:"relation,no-code-modules"`
+}
+
+type noCodeRegistryModuleList struct {
+	*tfe.Pagination
+	Items []*noCodeRegistryModule
+}
+
+// ScrapeNoCodeModules scrapes the no-code provisioning adoption of the private registry modules.
+type ScrapeNoCodeModules struct{}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeNoCodeModules{})
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeNoCodeModules) Name() string {
+	return noCodeModulesSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeNoCodeModules) Help() string {
+	return "Scrape information from the No-Code Provisioning API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/no-code-provisioning"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeNoCodeModules) Version() string {
+	return "v2"
+}
+
+// listNoCodeRegistryModules returns the registry modules of an organization having a no-code module.
+func listNoCodeRegistryModules(ctx context.Context, organization string, config *setup.Config) ([]*noCodeRegistryModule, error) {
+	var modules []*noCodeRegistryModule
+	for page := 1; ; page++ {
+		req, err := config.Client.NewRequest("GET", fmt.Sprintf("organizations/%s/registry-modules", url.PathEscape(organization)), &tfe.RegistryModuleListOptions{
+			ListOptions: tfe.ListOptions{PageSize: config.APIPageSize, PageNumber: page},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		moduleList := &noCodeRegistryModuleList{}
+		if err := req.Do(ctx, moduleList); err != nil {
+			return nil, fmt.Errorf("%v, (organization=%s, page=%d)", err, organization, page)
+		}
+
+		for _, m := range moduleList.Items {
+			if m.NoCode || len(m.NoCodeModules) > 0 {
+				modules = append(modules, m)
+			}
+		}
+		if moduleList.Pagination == nil || page >= moduleList.Pagination.TotalPages {
+			return modules, nil
+		}
+	}
+}
+
+func getNoCodeModule(ctx context.Context, m *noCodeRegistryModule, organization string, workspaces []*tfe.Workspace, config *setup.Config, ch chan<- prometheus.Metric) error {
+	noCode := &tfe.RegistryNoCodeModule{}
+	if len(m.NoCodeModules) > 0 {
+		var err error
+		noCode, err = config.Client.RegistryNoCodeModules.Read(ctx, m.NoCodeModules[0].ID, nil)
+		if err != nil {
+			return fmt.Errorf("%v, (organization=%s, module=%s, provider=%s)", err, organization, m.Name, m.Provider)
+		}
+	}
+
+	latest := getLatestModuleVersion(m.VersionStatuses)
+	// An empty pin means the no-code module always follows the latest version.
+	pinnedLatest := noCode.VersionPin == "" || noCode.VersionPin == latest
+
+	provisioned := 0
+	for _, w := range workspaces {
+		if isProvisionedFrom(w, m) {
+			provisioned++
+		}
+	}
+
+	for _, metric := range []prometheus.Metric{
+		prometheus.MustNewConstMetric(NoCodeModulesInfo, prometheus.GaugeValue, 1, organization, m.Name, m.Provider, noCode.ID, fmt.Sprintf("%t", noCode.Enabled || m.NoCode), noCode.VersionPin, latest),
+		prometheus.MustNewConstMetric(NoCodeModulesPinnedLatest, prometheus.GaugeValue, boolToFloat(pinnedLatest), organization, m.Name, m.Provider),
+		prometheus.MustNewConstMetric(NoCodeModulesWorkspaces, prometheus.GaugeValue, float64(provisioned), organization, m.Name, m.Provider),
+	} {
+		select {
+		case ch <- metric:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (ScrapeNoCodeModules) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			modules, err := listNoCodeRegistryModules(ctx, name, config)
+			if err != nil || len(modules) == 0 {
+				return err
+			}
+
+			workspaces, err := listWorkspaces(ctx, name, config)
+			if err != nil {
+				return err
+			}
+
+			for _, m := range modules {
+				if err := getNoCodeModule(ctx, m, name, workspaces, config, ch); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+// isProvisionedFrom tells whether a workspace was provisioned from a no-code module, in which case its
+// source URL points back to the module with a <namespace>/<name>/<provider> path.
+func isProvisionedFrom(w *tfe.Workspace, m *noCodeRegistryModule) bool {
+	if w.SourceURL == "" {
+		return false
+	}
+	u, err := url.Parse(w.SourceURL)
+	if err != nil {
+		return false
+	}
+
+	// Compare whole segments, so that a provider doesn't match the ones it prefixes.
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+2 < len(segments); i++ {
+		if segments[i] == m.Namespace && segments[i+1] == m.Name && segments[i+2] == m.Provider {
+			return true
+		}
+	}
+	return false
+}
+
+// getLatestModuleVersion returns the highest successfully published version of a module.
+func getLatestModuleVersion(statuses []tfe.RegistryModuleVersionStatuses) string {
+	var latest *version.Version
+	for _, s := range statuses {
+		if s.Status != tfe.RegistryModuleVersionStatusOk {
+			continue
+		}
+
+		v, err := version.NewVersion(s.Version)
+		if err != nil {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+	}
+
+	if latest == nil {
+		return ""
+	}
+	return latest.Original()
+}
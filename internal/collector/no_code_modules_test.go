@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeNoCodeModules(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/test-org/registry-modules", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":2}
+			},
+			"data":[{
+				"id":"mod-1",
+				"type":"registry-modules",
+				"attributes":{
+					"name":"bucket",
+					"provider":"aws",
+					"namespace":"test-org",
+					"no-code":true,
+					"version-statuses":[
+						{"version":"1.2.0","status":"ok"},
+						{"version":"1.10.0","status":"ok"},
+						{"version":"2.0.0","status":"reg_ingress_failed"}
+					]
+				},
+				"relationships":{"no-code-modules":{"data":[{"id":"nocode-1","type":"no-code-modules"}]}}
+			}, {
+				"id":"mod-2",
+				"type":"registry-modules",
+				"attributes":{"name":"vpc","provider":"aws","namespace":"test-org","no-code":false}
+			}]
+		}`))
+	})
+	mux.HandleFunc("/api/v2/no-code-modules/nocode-1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"id":"nocode-1","type":"no-code-modules","attributes":{"enabled":true,"version-pin":"1.2.0"}}}`))
+	})
+	mux.HandleFunc("/api/v2/organizations/test-org/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":5}
+			},
+			"data":[
+				{"id":"ws-1","type":"workspaces","attributes":{"name":"bucket-a","source-url":"https://app.terraform.io/app/test-org/registry/modules/private/test-org/bucket/aws"}},
+				{"id":"ws-4","type":"workspaces","attributes":{"name":"bucket-extra","source-url":"https://app.terraform.io/app/test-org/registry/modules/private/test-org/bucket/aws-extra"}},
+				{"id":"ws-5","type":"workspaces","attributes":{"name":"bucket-policy","source-url":"https://app.terraform.io/app/test-org/registry/modules/private/test-org/bucket-policy/aws"}},
+				{"id":"ws-2","type":"workspaces","attributes":{"name":"bucket-b","source-url":"https://app.terraform.io/app/test-org/registry/modules/private/test-org/bucket/aws"}},
+				{"id":"ws-3","type":"workspaces","attributes":{"name":"other"}}
+			]
+		}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		if err = (ScrapeNoCodeModules{}).Scrape(context.Background(), config, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+	}()
+
+	module := labelMap{"organization": "test-org", "module": "bucket", "provider": "aws"}
+	counterExpected := []MetricResult{
+		{labels: labelMap{"organization": "test-org", "module": "bucket", "provider": "aws", "id": "nocode-1", "enabled": "true", "version_pin": "1.2.0", "latest_version": "1.10.0"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: module, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: module, value: 2, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range counterExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, more := <-ch
+		convey.So(more, convey.ShouldBeFalse)
+	})
+}
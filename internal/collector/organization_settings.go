@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// organizationSettings is the Metric subsystem we use.
+	organizationSettingsSubsystem = "organization_settings"
+)
+
+// Metric descriptors.
+var (
+	OrganizationSettingsCostEstimation = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, organizationSettingsSubsystem, "cost_estimation_enabled"),
+		"Whether cost estimation is enabled for the organization (1 for true, 0 for false).",
+		[]string{"organization"}, nil,
+	)
+	OrganizationSettingsAssessmentsEnforced = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, organizationSettingsSubsystem, "assessments_enforced"),
+		"Whether health assessments are enforced on every workspace of the organization (1 for true, 0 for false).",
+		[]string{"organization"}, nil,
+	)
+	OrganizationSettingsExecutionMode = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, organizationSettingsSubsystem, "default_execution_mode"),
+		"Default execution mode (remote, local or agent) of the organization workspaces.",
+		[]string{"organization", "mode"}, nil,
+	)
+	OrganizationSettingsAuthPolicy = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, organizationSettingsSubsystem, "collaborator_auth_policy"),
+		"Authentication policy (password or two_factor_mandatory) required from the organization members.",
+		[]string{"organization", "policy"}, nil,
+	)
+	OrganizationSettingsSessionTimeout = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, organizationSettingsSubsystem, "session_timeout_minutes"),
+		"Session idle timeout of the organization members (0 when using the default).",
+		[]string{"organization"}, nil,
+	)
+	OrganizationSettingsSessionRemember = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, organizationSettingsSubsystem, "session_remember_minutes"),
+		"Session expiration of the organization members (0 when using the default).",
+		[]string{"organization"}, nil,
+	)
+	OrganizationSettingsDataRetentionPolicy = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, organizationSettingsSubsystem, "data_retention_policy"),
+		"Data retention policy (delete_older, dont_delete or none) of the organization. Only available in Terraform Enterprise.",
+		[]string{"organization", "policy"}, nil,
+	)
+	OrganizationSettingsDataRetentionDays = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, organizationSettingsSubsystem, "data_retention_days"),
+		"Number of days the organization data is retained for, when using a delete_older data retention policy.",
+		[]string{"organization"}, nil,
+	)
+)
+
+// ScrapeOrganizationSettings scrapes the settings of the organizations.
+type ScrapeOrganizationSettings struct{}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeOrganizationSettings{})
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeOrganizationSettings) Name() string {
+	return organizationSettingsSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeOrganizationSettings) Help() string {
+	return "Scrape settings from the Organizations API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/organizations"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeOrganizationSettings) Version() string {
+	return "v2"
+}
+
+func getOrganizationSettings(ctx context.Context, name string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	o, err := config.Client.Organizations.Read(ctx, name)
+	if err != nil {
+		return fmt.Errorf("%v, organization=%s", err, name)
+	}
+
+	metrics := []prometheus.Metric{
+		prometheus.MustNewConstMetric(OrganizationSettingsCostEstimation, prometheus.GaugeValue, boolToFloat(o.CostEstimationEnabled), o.Name),
+		prometheus.MustNewConstMetric(OrganizationSettingsAssessmentsEnforced, prometheus.GaugeValue, boolToFloat(o.AssessmentsEnforced), o.Name),
+		prometheus.MustNewConstMetric(OrganizationSettingsExecutionMode, prometheus.GaugeValue, 1, o.Name, o.DefaultExecutionMode),
+		prometheus.MustNewConstMetric(OrganizationSettingsAuthPolicy, prometheus.GaugeValue, 1, o.Name, string(o.CollaboratorAuthPolicy)),
+		prometheus.MustNewConstMetric(OrganizationSettingsSessionTimeout, prometheus.GaugeValue, float64(o.SessionTimeout), o.Name),
+		prometheus.MustNewConstMetric(OrganizationSettingsSessionRemember, prometheus.GaugeValue, float64(o.SessionRemember), o.Name),
+	}
+
+	// The organization only tells which kind of policy it uses, reading its details requires another call.
+	policy := "none"
+	if o.DataRetentionPolicyChoice != nil && o.DataRetentionPolicyChoice.IsPopulated() {
+		drp, err := config.Client.Organizations.ReadDataRetentionPolicyChoice(ctx, name)
+		if err != nil {
+			return fmt.Errorf("%v, organization=%s", err, name)
+		}
+
+		switch {
+		case drp.DataRetentionPolicyDeleteOlder != nil:
+			policy = "delete_older"
+			metrics = append(metrics, prometheus.MustNewConstMetric(OrganizationSettingsDataRetentionDays, prometheus.GaugeValue, float64(drp.DataRetentionPolicyDeleteOlder.DeleteOlderThanNDays), o.Name))
+		case drp.DataRetentionPolicyDontDelete != nil:
+			policy = "dont_delete"
+		}
+	}
+	metrics = append(metrics, prometheus.MustNewConstMetric(OrganizationSettingsDataRetentionPolicy, prometheus.GaugeValue, 1, o.Name, policy))
+
+	for _, m := range metrics {
+		select {
+		case ch <- m:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (ScrapeOrganizationSettings) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			return getOrganizationSettings(ctx, name, config, ch)
+		})
+	}
+
+	return g.Wait()
+}
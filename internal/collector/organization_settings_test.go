@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeOrganizationSettings(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/test-org", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"data": {
+				"id":"test-org",
+				"type":"organizations",
+				"attributes": {
+					"cost-estimation-enabled":true,
+					"assessments-enforced":false,
+					"default-execution-mode":"remote",
+					"collaborator-auth-policy":"two_factor_mandatory",
+					"session-timeout":20160,
+					"session-remember":0
+				},
+				"relationships": {
+					"data-retention-policy":{"data":{"id":"drp-1","type":"data-retention-policy-delete-olders"}}
+				}
+			}
+		}`))
+	})
+	mux.HandleFunc("/api/v2/organizations/test-org/relationships/data-retention-policy", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"id":"drp-1","type":"data-retention-policy-delete-olders","attributes":{"delete-older-than-n-days":90}}}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		if err = (ScrapeOrganizationSettings{}).Scrape(context.Background(), config, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+	}()
+
+	org := labelMap{"organization": "test-org"}
+	counterExpected := []MetricResult{
+		{labels: org, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: org, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "mode": "remote"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "policy": "two_factor_mandatory"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: org, value: 20160, metricType: dto.MetricType_GAUGE},
+		{labels: org, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: org, value: 90, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "policy": "delete_older"}, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range counterExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+}
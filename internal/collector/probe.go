@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metric descriptors.
+var (
+	probeSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "probe", "success"),
+		"Whether every collector succeeded to probe the organization (1 for success, 0 for error).",
+		nil, nil,
+	)
+	probeDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "probe", "duration_seconds"),
+		"Time the probe of the organization took.",
+		nil, nil,
+	)
+)
+
+// probe scrapes an organization when collected, for the blackbox_exporter like /probe endpoint.
+type probe struct {
+	ctx          context.Context
+	exporter     *Exporter
+	organization string
+}
+
+// Probe returns a prometheus.Collector scraping an organization with the collectors enabled for it, each time it is collected.
+// The organization doesn't need to be configured, so that Prometheus service discovery can provide it.
+func (e *Exporter) Probe(ctx context.Context, organization string) prometheus.Collector {
+	return probe{ctx: ctx, exporter: e, organization: organization}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (p probe) Describe(ch chan<- *prometheus.Desc) {
+	ch <- probeSuccessDesc
+	ch <- probeDurationDesc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (p probe) Collect(ch chan<- prometheus.Metric) {
+	p.exporter.mtx.RLock()
+	config, logger, scrapers := p.exporter.config, p.exporter.logger, p.exporter.scrapers
+	p.exporter.mtx.RUnlock()
+	config.Organizations = []string{p.organization}
+
+	probeTime := time.Now()
+	ctx := withInventory(p.ctx, newInventory(p.ctx, &config))
+	var (
+		wg      sync.WaitGroup
+		failed  int32
+		metrics = make(chan prometheus.Metric)
+	)
+	for _, scraper := range scrapers {
+		if !config.CollectorEnabled(scraper.Name(), p.organization) {
+			continue
+		}
+		// Probes are independent from the collections, don't share their state.
+		if stateful, ok := scraper.(statefulScraper); ok {
+			scraper = stateful.withNewState()
+		}
+
+		wg.Add(1)
+		go func(scraper Scraper) {
+			defer wg.Done()
+			if err := scraper.Scrape(ctx, &config, metrics); err != nil {
+				level.Error(logger).Log("msg", "Error from scraper", "scraper", scraper.Name(), "organization", p.organization, "err", err)
+				atomic.StoreInt32(&failed, 1)
+			}
+		}(scraper)
+	}
+	go func() {
+		wg.Wait()
+		close(metrics)
+	}()
+
+	// Some scrapers, like the audit trail one, aren't bound to the organizations they are given.
+	f := Filter{Organizations: config.Organizations}
+	for m := range metrics {
+		if f.matchMetric(m) {
+			ch <- m
+		}
+	}
+
+	success := 1.0
+	if atomic.LoadInt32(&failed) == 1 {
+		success = 0
+	}
+	ch <- prometheus.MustNewConstMetric(probeSuccessDesc, prometheus.GaugeValue, success)
+	ch <- prometheus.MustNewConstMetric(probeDurationDesc, prometheus.GaugeValue, time.Since(probeTime).Seconds())
+}
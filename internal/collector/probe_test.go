@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestExporterProbe(t *testing.T) {
+	value, err := 1.0, error(nil)
+	e := New(setup.Config{
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+		Logger: log.NewNopLogger(),
+	}, NewMetrics())
+	e.scrapers = []Scraper{stubScraper{value: &value, err: &err}}
+
+	probe := func() []MetricResult {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			e.Probe(context.Background(), "discovered-org").Collect(ch)
+		}()
+		var results []MetricResult
+		for m := range ch {
+			results = append(results, readMetric(m))
+		}
+		return results
+	}
+
+	convey.Convey("The target organization is scraped", t, func() {
+		results := probe()
+		convey.So(results, convey.ShouldHaveLength, 3)
+		convey.So(results[0], convey.ShouldResemble, MetricResult{labels: labelMap{"organization": "discovered-org"}, value: 1, metricType: dto.MetricType_GAUGE})
+		convey.So(results[1], convey.ShouldResemble, MetricResult{labels: labelMap{}, value: 1, metricType: dto.MetricType_GAUGE})
+	})
+
+	err = errors.New("API unavailable")
+	convey.Convey("A failing collector fails the probe", t, func() {
+		results := probe()
+		convey.So(results, convey.ShouldHaveLength, 2)
+		convey.So(results[0], convey.ShouldResemble, MetricResult{labels: labelMap{}, value: 0, metricType: dto.MetricType_GAUGE})
+	})
+}
+
+// countingScraper counts its scrapes in its state.
+type countingScraper struct {
+	count *int
+}
+
+func (countingScraper) Name() string    { return "counting" }
+func (countingScraper) Help() string    { return "Counting scraper." }
+func (countingScraper) Version() string { return "v2" }
+
+func (countingScraper) withNewState() Scraper {
+	return countingScraper{count: new(int)}
+}
+
+func (s countingScraper) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	*s.count++
+	return nil
+}
+
+func TestExporterProbeState(t *testing.T) {
+	e := New(setup.Config{
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+		Logger: log.NewNopLogger(),
+	}, NewMetrics())
+	scraper := countingScraper{count: new(int)}
+	e.scrapers = []Scraper{scraper}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		e.Probe(context.Background(), "discovered-org").Collect(ch)
+	}()
+	for range ch {
+	}
+
+	convey.Convey("Probes don't share the state of the collections", t, func() {
+		convey.So(*scraper.count, convey.ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// remoteState is the Metric subsystem we use.
+	remoteStateSubsystem = "remote_state"
+)
+
+// Metric descriptors.
+var (
+	RemoteStateConsumers = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, remoteStateSubsystem, "consumers"),
+		"Number of workspaces allowed to read the state of a workspace, every other workspace of the organization when it is shared globally.",
+		[]string{"organization", "workspace"}, nil,
+	)
+)
+
+// ScrapeRemoteState scrapes the remote state sharing of the workspaces.
+type ScrapeRemoteState struct{}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeRemoteState{})
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeRemoteState) Name() string {
+	return remoteStateSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeRemoteState) Help() string {
+	return "Scrape information from the Remote State Consumers API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/workspaces#get-remote-state-consumers"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeRemoteState) Version() string {
+	return "v2"
+}
+
+func getRemoteStateConsumers(ctx context.Context, w *tfe.Workspace, organization string, workspaceCount int, config *setup.Config, ch chan<- prometheus.Metric) error {
+	var metrics []prometheus.Metric
+	// The global sharing is a workspace setting, only report it here when the workspace_settings collector doesn't.
+	if !config.CollectorEnabled(workspaceSettingsSubsystem, organization) {
+		metrics = append(metrics, prometheus.MustNewConstMetric(WorkspaceGlobalRemoteState, prometheus.GaugeValue, boolToFloat(w.GlobalRemoteState), organization, w.Name))
+	}
+
+	// The consumers are ignored while the state is shared globally.
+	count := workspaceCount - 1
+	if !w.GlobalRemoteState {
+		// We only need the total count from the pagination.
+		consumers, err := config.Client.Workspaces.ListRemoteStateConsumers(ctx, w.ID, &tfe.RemoteStateConsumersListOptions{
+			ListOptions: tfe.ListOptions{PageSize: 1},
+		})
+		if err != nil {
+			return fmt.Errorf("%v, (organization=%s, workspace=%s)", err, organization, w.Name)
+		}
+
+		count = len(consumers.Items)
+		if consumers.Pagination != nil {
+			count = consumers.Pagination.TotalCount
+		}
+	}
+	metrics = append(metrics, prometheus.MustNewConstMetric(RemoteStateConsumers, prometheus.GaugeValue, float64(count), organization, w.Name))
+
+	for _, m := range metrics {
+		select {
+		case ch <- m:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (ScrapeRemoteState) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			workspaces, err := listWorkspaces(ctx, name, config)
+			if err != nil {
+				return err
+			}
+
+			for _, w := range workspaces {
+				if err := getRemoteStateConsumers(ctx, w, name, len(workspaces), config, ch); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
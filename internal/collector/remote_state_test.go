@@ -0,0 +1,107 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeRemoteState(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/test-org/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":2}
+			},
+			"data":[{
+				"id":"ws-prod",
+				"type":"workspaces",
+				"attributes":{"name":"prod","global-remote-state":true}
+			}, {
+				"id":"ws-network",
+				"type":"workspaces",
+				"attributes":{"name":"network","global-remote-state":false}
+			}]
+		}`))
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-network/relationships/remote-state-consumers", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":2,"total-pages":3,"total-count":3}
+			},
+			"data":[{
+				"id":"ws-app",
+				"type":"workspaces",
+				"attributes":{"name":"app"}
+			}]
+		}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	scrape := func(config *setup.Config) []MetricResult {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			defer close(ch)
+			if err := (ScrapeRemoteState{}).Scrape(context.Background(), config, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+		}()
+
+		var got []MetricResult
+		for m := range ch {
+			got = append(got, readMetric(m))
+		}
+		return got
+	}
+
+	prod := labelMap{"organization": "test-org", "workspace": "prod"}
+	network := labelMap{"organization": "test-org", "workspace": "network"}
+
+	convey.Convey("A globally shared state can be read by every other workspace", t, func() {
+		got := scrape(&setup.Config{
+			Client: *client,
+			CLI:    setup.CLI{Organizations: []string{"test-org"}},
+		})
+		convey.So(got, convey.ShouldResemble, []MetricResult{
+			{labels: prod, value: 1, metricType: dto.MetricType_GAUGE},
+			{labels: network, value: 3, metricType: dto.MetricType_GAUGE},
+		})
+	})
+
+	convey.Convey("The global sharing is reported when the workspace_settings collector is disabled", t, func() {
+		got := scrape(&setup.Config{
+			Client:     *client,
+			CLI:        setup.CLI{Organizations: []string{"test-org"}},
+			Collectors: map[string]bool{remoteStateSubsystem: true, workspaceSettingsSubsystem: false},
+		})
+		convey.So(got, convey.ShouldResemble, []MetricResult{
+			{labels: prod, value: 1, metricType: dto.MetricType_GAUGE},
+			{labels: prod, value: 1, metricType: dto.MetricType_GAUGE},
+			{labels: network, value: 0, metricType: dto.MetricType_GAUGE},
+			{labels: network, value: 3, metricType: dto.MetricType_GAUGE},
+		})
+	})
+}
@@ -0,0 +1,131 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// runQueue is the Metric subsystem we use.
+	runQueueSubsystem = "run_queue"
+)
+
+// Metric descriptors.
+var (
+	RunQueueQueued = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, runQueueSubsystem, "queued_runs"),
+		"Number of runs waiting in the organization run queue.",
+		[]string{"organization"}, nil,
+	)
+	RunQueueExecuting = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, runQueueSubsystem, "executing_runs"),
+		"Number of runs of the organization run queue currently being executed.",
+		[]string{"organization"}, nil,
+	)
+	RunQueueOldestAge = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, runQueueSubsystem, "oldest_queued_run_age_seconds"),
+		"Age of the oldest run waiting in the organization run queue (0 when nothing is queued).",
+		[]string{"organization"}, nil,
+	)
+)
+
+// ScrapeRunQueue scrapes metrics about the organizations run queue.
+type ScrapeRunQueue struct{}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeRunQueue{})
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeRunQueue) Name() string {
+	return runQueueSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeRunQueue) Help() string {
+	return "Scrape information from the Organization Run Queue API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/organizations#show-the-run-queue"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeRunQueue) Version() string {
+	return "v2"
+}
+
+func getRunQueue(ctx context.Context, name string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	var queued, executing int
+	var oldest time.Time
+	for page := 1; ; page++ {
+		queue, err := config.Client.Organizations.ReadRunQueue(ctx, name, tfe.ReadRunQueueOptions{
+			ListOptions: tfe.ListOptions{PageSize: config.APIPageSize, PageNumber: page},
+		})
+		if err != nil {
+			return fmt.Errorf("%v, (organization=%s, page=%d)", err, name, page)
+		}
+
+		for _, r := range queue.Items {
+			if !isRunQueued(r.Status) {
+				executing++
+				continue
+			}
+
+			queued++
+			if oldest.IsZero() || r.CreatedAt.Before(oldest) {
+				oldest = r.CreatedAt
+			}
+		}
+
+		if queue.Pagination == nil || page >= queue.Pagination.TotalPages {
+			break
+		}
+	}
+
+	var oldestAge float64
+	if !oldest.IsZero() {
+		oldestAge = now().Sub(oldest).Seconds()
+	}
+
+	for _, m := range []prometheus.Metric{
+		prometheus.MustNewConstMetric(RunQueueQueued, prometheus.GaugeValue, float64(queued), name),
+		prometheus.MustNewConstMetric(RunQueueExecuting, prometheus.GaugeValue, float64(executing), name),
+		prometheus.MustNewConstMetric(RunQueueOldestAge, prometheus.GaugeValue, oldestAge, name),
+	} {
+		select {
+		case ch <- m:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (ScrapeRunQueue) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			return getRunQueue(ctx, name, config, ch)
+		})
+	}
+
+	return g.Wait()
+}
+
+// isRunQueued reports whether a run of the queue is still waiting for a worker.
+func isRunQueued(s tfe.RunStatus) bool {
+	switch s {
+	case tfe.RunPending, tfe.RunPlanQueued, tfe.RunQueuing, tfe.RunApplyQueued, tfe.RunQueuingApply:
+		return true
+	default:
+		return false
+	}
+}
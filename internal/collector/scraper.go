@@ -22,3 +22,72 @@ type Scraper interface {
 	// Scrape collects data from a particular terraform cloud/enterprise API and sends it over channel as prometheus metric.
 	Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error
 }
+
+// statefulScraper is implemented by the scrapers carrying state between scrapes,
+// so that each Exporter gets its own state.
+type statefulScraper interface {
+	Scraper
+	withNewState() Scraper
+}
+
+// newScrapers returns the registered scrapers, with their own state.
+func newScrapers() []Scraper {
+	scrapers := make([]Scraper, 0, len(Scrapers))
+	for _, s := range Scrapers {
+		if stateful, ok := s.(statefulScraper); ok {
+			s = stateful.withNewState()
+		}
+		scrapers = append(scrapers, s)
+	}
+
+	return scrapers
+}
+
+// optInScrapers are disabled by default, as they make at least one API call per workspace or module,
+// or need a paid tier.
+var optInScrapers = map[string]bool{
+	billableResourcesSubsystem:     true,
+	configurationVersionsSubsystem: true,
+	currentRunSubsystem:            true,
+	explorerSubsystem:              true,
+	moduleTestsSubsystem:           true,
+	noCodeModulesSubsystem:         true,
+	remoteStateSubsystem:           true,
+	staleWorkspacesSubsystem:       true,
+	teamAccessSubsystem:            true,
+}
+
+// Collectors describes the registered scrapers so they can be toggled from the command line.
+func Collectors() []setup.Collector {
+	collectors := make([]setup.Collector, 0, len(Scrapers))
+	for _, s := range Scrapers {
+		collectors = append(collectors, setup.Collector{
+			Name:           s.Name(),
+			Help:           s.Help(),
+			EnabledDefault: !optInScrapers[s.Name()],
+		})
+	}
+
+	return collectors
+}
+
+// enabledScrapers returns the scrapers enabled in the config, for any organization.
+// All of them are enabled if it doesn't tell.
+func enabledScrapers(all []Scraper, config setup.Config) []Scraper {
+	if config.Collectors == nil {
+		return all
+	}
+
+	var scrapers []Scraper
+	for _, s := range all {
+		enabled := config.Collectors[s.Name()]
+		for _, settings := range config.OrganizationSettings {
+			enabled = enabled || settings.Collectors[s.Name()]
+		}
+		if enabled {
+			scrapers = append(scrapers, s)
+		}
+	}
+
+	return scrapers
+}
@@ -0,0 +1,137 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// staleWorkspaces is the Metric subsystem we use.
+	staleWorkspacesSubsystem = "stale_workspaces"
+)
+
+// Metric descriptors.
+var (
+	WorkspaceSinceLatestChange = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "workspace", "seconds_since_latest_change"),
+		"Seconds since the latest change (run or state change) of the workspace.",
+		[]string{"organization", "workspace"}, nil,
+	)
+	WorkspaceSinceLastApply = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "workspace", "seconds_since_last_apply"),
+		"Seconds since the last successful apply of the workspace. Not reported for workspaces never applied.",
+		[]string{"organization", "workspace"}, nil,
+	)
+	IdleWorkspaces = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "idle_workspaces"),
+		"Number of workspaces without any change nor apply for longer than the configured idle threshold.",
+		[]string{"organization"}, nil,
+	)
+)
+
+// ScrapeStaleWorkspaces scrapes how long the workspaces have been idle.
+type ScrapeStaleWorkspaces struct{}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeStaleWorkspaces{})
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeStaleWorkspaces) Name() string {
+	return staleWorkspacesSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeStaleWorkspaces) Help() string {
+	return "Scrape workspace activity from the Workspaces and Runs APIs: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/run"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeStaleWorkspaces) Version() string {
+	return "v2"
+}
+
+// getLastApply returns when the workspace was last applied successfully, or the zero time if it never was.
+func getLastApply(ctx context.Context, w *tfe.Workspace, organization string, config *setup.Config) (time.Time, error) {
+	// Runs are listed newest first.
+	runs, err := config.Client.Runs.List(ctx, w.ID, &tfe.RunListOptions{
+		ListOptions: tfe.ListOptions{PageSize: 1},
+		Status:      string(tfe.RunApplied),
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%v, (organization=%s, workspace=%s)", err, organization, w.Name)
+	}
+	if len(runs.Items) == 0 || runs.Items[0].StatusTimestamps == nil {
+		return time.Time{}, nil
+	}
+
+	return runs.Items[0].StatusTimestamps.AppliedAt, nil
+}
+
+func getStaleWorkspaces(ctx context.Context, organization string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	inventory, err := getInventory(ctx, config).organization(ctx, organization)
+	if err != nil {
+		return err
+	}
+
+	threshold := config.ForOrganization(organization).WorkspaceIdleThreshold
+	idle := 0
+	for _, w := range inventory.workspaces {
+		lastApply, err := getLastApply(ctx, w, organization, config)
+		if err != nil {
+			return err
+		}
+
+		lastActivity := inventory.latestChanges[w.ID]
+		var metrics []prometheus.Metric
+		if !lastActivity.IsZero() {
+			metrics = append(metrics, prometheus.MustNewConstMetric(WorkspaceSinceLatestChange, prometheus.GaugeValue, now().Sub(lastActivity).Seconds(), organization, w.Name))
+		}
+		if !lastApply.IsZero() {
+			metrics = append(metrics, prometheus.MustNewConstMetric(WorkspaceSinceLastApply, prometheus.GaugeValue, now().Sub(lastApply).Seconds(), organization, w.Name))
+			if lastApply.After(lastActivity) {
+				lastActivity = lastApply
+			}
+		}
+		if !lastActivity.IsZero() && now().Sub(lastActivity) > threshold {
+			idle++
+		}
+
+		for _, m := range metrics {
+			select {
+			case ch <- m:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	select {
+	case ch <- prometheus.MustNewConstMetric(IdleWorkspaces, prometheus.GaugeValue, float64(idle), organization):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (ScrapeStaleWorkspaces) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			return getStaleWorkspaces(ctx, name, config, ch)
+		})
+	}
+
+	return g.Wait()
+}
@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// teamAccess is the Metric subsystem we use.
+	teamAccessSubsystem = "team_access"
+)
+
+// Metric descriptors.
+var (
+	TeamAccessInfo = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, teamAccessSubsystem, "info"),
+		"Access level (read, plan, write, admin or custom) granted to a team on a workspace.",
+		[]string{"organization", "workspace", "team", "access"}, nil,
+	)
+	TeamAccessAdminTeams = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, teamAccessSubsystem, "admin_teams"),
+		"Number of teams with admin access to a workspace.",
+		[]string{"organization", "workspace"}, nil,
+	)
+)
+
+// ScrapeTeamAccess scrapes the teams access granted on the workspaces.
+type ScrapeTeamAccess struct{}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeTeamAccess{})
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeTeamAccess) Name() string {
+	return teamAccessSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeTeamAccess) Help() string {
+	return "Scrape information from the Team Access API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/team-access"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeTeamAccess) Version() string {
+	return "v2"
+}
+
+// listTeamNames returns the name of every team of an organization, indexed by team ID.
+func listTeamNames(ctx context.Context, organization string, config *setup.Config) (map[string]string, error) {
+	names := map[string]string{}
+	for page := 1; ; page++ {
+		teamList, err := config.Client.Teams.List(ctx, organization, &tfe.TeamListOptions{
+			ListOptions: tfe.ListOptions{PageSize: config.APIPageSize, PageNumber: page},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%v, (organization=%s, page=%d)", err, organization, page)
+		}
+
+		for _, t := range teamList.Items {
+			names[t.ID] = t.Name
+		}
+		if teamList.Pagination == nil || page >= teamList.Pagination.TotalPages {
+			return names, nil
+		}
+	}
+}
+
+func getTeamAccess(ctx context.Context, w *tfe.Workspace, organization string, teams map[string]string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	adminTeams := 0
+	for page := 1; ; page++ {
+		accessList, err := config.Client.TeamAccess.List(ctx, &tfe.TeamAccessListOptions{
+			ListOptions: tfe.ListOptions{PageSize: config.APIPageSize, PageNumber: page},
+			WorkspaceID: w.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("%v, (organization=%s, workspace=%s, page=%d)", err, organization, w.Name, page)
+		}
+
+		for _, ta := range accessList.Items {
+			if ta.Access == tfe.AccessAdmin {
+				adminTeams++
+			}
+
+			team := ""
+			if ta.Team != nil {
+				team = teams[ta.Team.ID]
+				if team == "" {
+					team = ta.Team.ID
+				}
+			}
+
+			select {
+			case ch <- prometheus.MustNewConstMetric(
+				TeamAccessInfo,
+				prometheus.GaugeValue,
+				1,
+				organization,
+				w.Name,
+				team,
+				string(ta.Access),
+			):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if accessList.Pagination == nil || page >= accessList.Pagination.TotalPages {
+			break
+		}
+	}
+
+	select {
+	case ch <- prometheus.MustNewConstMetric(TeamAccessAdminTeams, prometheus.GaugeValue, float64(adminTeams), organization, w.Name):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (ScrapeTeamAccess) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			teams, err := listTeamNames(ctx, name, config)
+			if err != nil {
+				return err
+			}
+
+			workspaces, err := listWorkspaces(ctx, name, config)
+			if err != nil {
+				return err
+			}
+
+			for _, w := range workspaces {
+				if err := getTeamAccess(ctx, w, name, teams, config, ch); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
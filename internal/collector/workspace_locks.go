@@ -0,0 +1,204 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// workspaceLocks is the Metric subsystem we use.
+	workspaceLocksSubsystem = "workspace_locks"
+)
+
+// Metric descriptors.
+var (
+	WorkspaceLocked = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "workspace", "locked"),
+		"Whether the workspace is locked (1 for locked, 0 for unlocked).",
+		[]string{"organization", "workspace"}, nil,
+	)
+	WorkspaceLockHolder = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "workspace", "lock_holder_info"),
+		"User, team or run holding the lock of a locked workspace.",
+		[]string{"organization", "workspace", "holder_type", "holder"}, nil,
+	)
+	WorkspaceLockDuration = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "workspace", "lock_duration_seconds"),
+		"How long a locked workspace has been locked. Locks not held by a run are timed from the first scrape that saw them.",
+		[]string{"organization", "workspace"}, nil,
+	)
+	WorkspaceLockExceeded = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "workspace", "lock_threshold_exceeded"),
+		"Whether a workspace has been locked for longer than the configured threshold (1 for exceeded, 0 otherwise).",
+		[]string{"organization", "workspace"}, nil,
+	)
+)
+
+// lockTimes remembers when the exporter first saw each workspace locked, indexed by workspace ID.
+type lockTimes struct {
+	sync.Mutex
+	since map[string]time.Time
+}
+
+// observe returns the time the workspace was first seen locked, forgetting it as soon as it is unlocked.
+func (l *lockTimes) observe(workspaceID string, locked bool) time.Time {
+	l.Lock()
+	defer l.Unlock()
+
+	if !locked {
+		delete(l.since, workspaceID)
+		return time.Time{}
+	}
+
+	since, ok := l.since[workspaceID]
+	if !ok {
+		since = now()
+		l.since[workspaceID] = since
+	}
+	return since
+}
+
+// prune forgets the workspaces which weren't listed, they were deleted or their organization is no longer scraped.
+func (l *lockTimes) prune(listed map[string]bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	for id := range l.since {
+		if !listed[id] {
+			delete(l.since, id)
+		}
+	}
+}
+
+// ScrapeWorkspaceLocks scrapes metrics about the workspace locks.
+type ScrapeWorkspaceLocks struct {
+	lockTimes *lockTimes
+}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeWorkspaceLocks{}.withNewState())
+}
+
+func (ScrapeWorkspaceLocks) withNewState() Scraper {
+	return ScrapeWorkspaceLocks{lockTimes: &lockTimes{since: map[string]time.Time{}}}
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeWorkspaceLocks) Name() string {
+	return workspaceLocksSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeWorkspaceLocks) Help() string {
+	return "Scrape lock information from the Workspaces API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/workspaces"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeWorkspaceLocks) Version() string {
+	return "v2"
+}
+
+func (s ScrapeWorkspaceLocks) getWorkspaceLock(ctx context.Context, w *tfe.Workspace, organization string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	since := s.lockTimes.observe(w.ID, w.Locked)
+	if !w.Locked {
+		select {
+		case ch <- prometheus.MustNewConstMetric(WorkspaceLocked, prometheus.GaugeValue, 0, organization, w.Name):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	}
+
+	holderType, holder := getLockHolder(w.LockedBy)
+	if w.LockedBy != nil && w.LockedBy.Run != nil && !w.LockedBy.Run.CreatedAt.IsZero() {
+		// Runs hold the lock for their whole life, which gives a more accurate start than our first sighting.
+		since = w.LockedBy.Run.CreatedAt
+	}
+
+	duration := now().Sub(since)
+	exceeded := 0.0
+	if duration > config.ForOrganization(organization).WorkspaceLockThreshold {
+		exceeded = 1
+	}
+
+	for _, m := range []prometheus.Metric{
+		prometheus.MustNewConstMetric(WorkspaceLocked, prometheus.GaugeValue, 1, organization, w.Name),
+		prometheus.MustNewConstMetric(WorkspaceLockHolder, prometheus.GaugeValue, 1, organization, w.Name, holderType, holder),
+		prometheus.MustNewConstMetric(WorkspaceLockDuration, prometheus.GaugeValue, duration.Seconds(), organization, w.Name),
+		prometheus.MustNewConstMetric(WorkspaceLockExceeded, prometheus.GaugeValue, exceeded, organization, w.Name),
+	} {
+		select {
+		case ch <- m:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (s ScrapeWorkspaceLocks) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	var mtx sync.Mutex
+	listed := map[string]bool{}
+
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			workspaces, err := listWorkspaces(ctx, name, config)
+			if err != nil {
+				return err
+			}
+
+			mtx.Lock()
+			for _, w := range workspaces {
+				listed[w.ID] = true
+			}
+			mtx.Unlock()
+
+			for _, w := range workspaces {
+				if err := s.getWorkspaceLock(ctx, w, name, config, ch); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	s.lockTimes.prune(listed)
+	return nil
+}
+
+func getLockHolder(l *tfe.LockedByChoice) (string, string) {
+	switch {
+	case l == nil:
+		return "na", "na"
+	case l.Run != nil:
+		return "run", l.Run.ID
+	case l.User != nil:
+		if l.User.Username != "" {
+			return "user", l.User.Username
+		}
+		return "user", l.User.ID
+	case l.Team != nil:
+		if l.Team.Name != "" {
+			return "team", l.Team.Name
+		}
+		return "team", l.Team.ID
+	default:
+		return "na", "na"
+	}
+}
@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeWorkspaceLocks(t *testing.T) {
+	now = func() time.Time { return time.Date(2020, 10, 10, 10, 10, 10, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/test-org/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":3}
+			},
+			"data":[{
+				"id":"ws-prod",
+				"type":"workspaces",
+				"attributes":{"name":"prod","locked":true},
+				"relationships":{"locked-by":{"data":{"id":"run-1","type":"runs"}}}
+			}, {
+				"id":"ws-stg",
+				"type":"workspaces",
+				"attributes":{"name":"stg","locked":true},
+				"relationships":{"locked-by":{"data":{"id":"user-1","type":"users"}}}
+			}, {
+				"id":"ws-dev",
+				"type":"workspaces",
+				"attributes":{"name":"dev","locked":false}
+			}],
+			"included":[
+				{"id":"run-1","type":"runs","attributes":{"created-at":"2020-10-10T08:10:10Z"}},
+				{"id":"user-1","type":"users","attributes":{"username":"jdoe"}}
+			]
+		}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI:    setup.CLI{Organizations: []string{"test-org"}, WorkspaceLockThreshold: time.Hour},
+	}
+
+	scraper := ScrapeWorkspaceLocks{lockTimes: &lockTimes{since: map[string]time.Time{
+		"ws-stg": now().Add(-30 * time.Minute),
+		"ws-dev": now().Add(-30 * time.Minute),
+		// Deleted since the last scrape.
+		"ws-old": now().Add(-30 * time.Minute),
+	}}}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		if err = scraper.Scrape(context.Background(), config, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+	}()
+
+	prod := labelMap{"organization": "test-org", "workspace": "prod"}
+	stg := labelMap{"organization": "test-org", "workspace": "stg"}
+	counterExpected := []MetricResult{
+		{labels: prod, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "workspace": "prod", "holder_type": "run", "holder": "run-1"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: prod, value: 7200, metricType: dto.MetricType_GAUGE},
+		{labels: prod, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: stg, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "workspace": "stg", "holder_type": "user", "holder": "jdoe"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: stg, value: 1800, metricType: dto.MetricType_GAUGE},
+		{labels: stg, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"organization": "test-org", "workspace": "dev"}, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range counterExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, more := <-ch
+		convey.So(more, convey.ShouldBeFalse)
+		convey.So(scraper.lockTimes.since, convey.ShouldNotContainKey, "ws-dev")
+		convey.So(scraper.lockTimes.since, convey.ShouldNotContainKey, "ws-old")
+	})
+}
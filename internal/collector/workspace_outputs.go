@@ -0,0 +1,154 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// workspaceOutputs is the Metric subsystem we use.
+	workspaceOutputsSubsystem = "workspace_outputs"
+)
+
+// Metric descriptors.
+var (
+	WorkspaceOutput = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "workspace", "output"),
+		"Value of the numeric and boolean (1 for true, 0 for false) outputs of the current workspace state. Sensitive outputs are never exported.",
+		[]string{"organization", "workspace", "output"}, nil,
+	)
+)
+
+// ScrapeWorkspaceOutputs scrapes the current state version outputs of the selected workspaces.
+type ScrapeWorkspaceOutputs struct{}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeWorkspaceOutputs{})
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeWorkspaceOutputs) Name() string {
+	return workspaceOutputsSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeWorkspaceOutputs) Help() string {
+	return "Scrape information from the State Version Outputs API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/state-version-outputs"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeWorkspaceOutputs) Version() string {
+	return "v2"
+}
+
+func getWorkspaceOutputs(ctx context.Context, w *tfe.Workspace, organization string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	outputs, err := config.Client.StateVersionOutputs.ReadCurrent(ctx, w.ID)
+	if err == tfe.ErrResourceNotFound {
+		// Workspaces without any state have no outputs to export.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%v, (organization=%s, workspace=%s)", err, organization, w.Name)
+	}
+
+	for _, o := range outputs.Items {
+		value, ok := getOutputValue(o)
+		if !ok {
+			continue
+		}
+
+		select {
+		case ch <- prometheus.MustNewConstMetric(
+			WorkspaceOutput,
+			prometheus.GaugeValue,
+			value,
+			organization,
+			w.Name,
+			o.Name,
+		):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func getOrganizationOutputs(ctx context.Context, organization string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	// Exporting outputs is opt-in, it only happens once the user selects which workspaces to read.
+	settings := config.ForOrganization(organization)
+	if settings.WorkspaceOutputsName == "" && len(settings.WorkspaceOutputsTags) == 0 {
+		return nil
+	}
+
+	nameFilter, err := regexp.Compile(settings.WorkspaceOutputsName)
+	if err != nil {
+		return fmt.Errorf("invalid workspace outputs name filter: %v, (organization=%s)", err, organization)
+	}
+
+	workspaces, err := listWorkspaces(ctx, organization, config)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range workspaces {
+		if !nameFilter.MatchString(w.Name) || !hasTags(w, settings.WorkspaceOutputsTags) {
+			continue
+		}
+		if err := getWorkspaceOutputs(ctx, w, organization, config, ch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (ScrapeWorkspaceOutputs) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			return getOrganizationOutputs(ctx, name, config, ch)
+		})
+	}
+
+	return g.Wait()
+}
+
+// hasTags tells whether the workspace has all of the tags.
+func hasTags(w *tfe.Workspace, tags []string) bool {
+	for _, tag := range tags {
+		if !contains(w.TagNames, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// getOutputValue converts an output into a metric value, reporting false for the outputs that can't be exported.
+func getOutputValue(o *tfe.StateVersionOutput) (float64, bool) {
+	if o.Sensitive {
+		return 0, false
+	}
+
+	switch v := o.Value.(type) {
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
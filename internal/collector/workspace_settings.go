@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// workspaceSettings is the Metric subsystem we use.
+	workspaceSettingsSubsystem = "workspace_settings"
+)
+
+// Metric descriptors.
+var (
+	WorkspaceExecutionMode = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "workspace", "execution_mode"),
+		"Execution mode (remote, local or agent) of the workspace.",
+		[]string{"organization", "workspace", "mode"}, nil,
+	)
+	WorkspaceAutoApply           = newWorkspaceSettingDesc("auto_apply", "Whether the workspace automatically applies successful plans")
+	WorkspaceSpeculativeEnabled  = newWorkspaceSettingDesc("speculative_enabled", "Whether the workspace allows speculative plans")
+	WorkspaceVCSConnected        = newWorkspaceSettingDesc("vcs_connected", "Whether the workspace is connected to a VCS repository")
+	WorkspaceWorkingDirectorySet = newWorkspaceSettingDesc("working_directory_set", "Whether the workspace runs Terraform from a working directory other than the repository root")
+	WorkspaceFileTriggersEnabled = newWorkspaceSettingDesc("file_triggers_enabled", "Whether the workspace only queues runs for changes to its trigger paths")
+	WorkspaceGlobalRemoteState   = newWorkspaceSettingDesc("global_remote_state", "Whether the workspace shares its state with every workspace of the organization")
+	WorkspaceQueueAllRuns        = newWorkspaceSettingDesc("queue_all_runs", "Whether the workspace queues runs as soon as it is created")
+	WorkspaceAssessmentsEnabled  = newWorkspaceSettingDesc("assessments_enabled", "Whether health assessments are enabled for the workspace")
+)
+
+// newWorkspaceSettingDesc describes a boolean workspace setting (1 for enabled, 0 for disabled).
+func newWorkspaceSettingDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "workspace", name),
+		help+" (1 for true, 0 for false).",
+		[]string{"organization", "workspace"}, nil,
+	)
+}
+
+// ScrapeWorkspaceSettings scrapes the execution settings of the workspaces.
+type ScrapeWorkspaceSettings struct{}
+
+func init() {
+	Scrapers = append(Scrapers, ScrapeWorkspaceSettings{})
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeWorkspaceSettings) Name() string {
+	return workspaceSettingsSubsystem
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeWorkspaceSettings) Help() string {
+	return "Scrape execution settings from the Workspaces API: https://developer.hashicorp.com/terraform/cloud-docs/api-docs/workspaces"
+}
+
+// Version of Terraform Cloud/Enterprise API from which scraper is available.
+func (ScrapeWorkspaceSettings) Version() string {
+	return "v2"
+}
+
+func getWorkspaceSettings(ctx context.Context, w *tfe.Workspace, organization string, ch chan<- prometheus.Metric) error {
+	settings := []struct {
+		desc    *prometheus.Desc
+		enabled bool
+	}{
+		{WorkspaceAutoApply, w.AutoApply},
+		{WorkspaceSpeculativeEnabled, w.SpeculativeEnabled},
+		{WorkspaceVCSConnected, w.VCSRepo != nil},
+		{WorkspaceWorkingDirectorySet, w.WorkingDirectory != ""},
+		{WorkspaceFileTriggersEnabled, w.FileTriggersEnabled},
+		{WorkspaceGlobalRemoteState, w.GlobalRemoteState},
+		{WorkspaceQueueAllRuns, w.QueueAllRuns},
+		{WorkspaceAssessmentsEnabled, w.AssessmentsEnabled},
+	}
+
+	metrics := []prometheus.Metric{
+		prometheus.MustNewConstMetric(WorkspaceExecutionMode, prometheus.GaugeValue, 1, organization, w.Name, w.ExecutionMode),
+	}
+	for _, s := range settings {
+		metrics = append(metrics, prometheus.MustNewConstMetric(s.desc, prometheus.GaugeValue, boolToFloat(s.enabled), organization, w.Name))
+	}
+
+	for _, m := range metrics {
+		select {
+		case ch <- m:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Scrape collects data from Terraform API and sends it over channel as prometheus metric.
+func (ScrapeWorkspaceSettings) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range config.Organizations {
+		name := name
+		g.Go(func() error {
+			workspaces, err := listWorkspaces(ctx, name, config)
+			if err != nil {
+				return err
+			}
+
+			for _, w := range workspaces {
+				if err := getWorkspaceSettings(ctx, w, name, ch); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
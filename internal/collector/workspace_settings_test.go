@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeWorkspaceSettings(t *testing.T) {
+	mockAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"meta":{
+				"pagination":{"current-page":1,"prev-page":null,"next-page":null,"total-pages":1,"total-count":1}
+			},
+			"data":[{
+				"id":"ws-prod",
+				"type":"workspaces",
+				"attributes":{
+					"name":"prod",
+					"execution-mode":"agent",
+					"auto-apply":true,
+					"speculative-enabled":true,
+					"vcs-repo":{"identifier":"test/repo","branch":"main"},
+					"working-directory":"",
+					"file-triggers-enabled":false,
+					"global-remote-state":true,
+					"queue-all-runs":false,
+					"assessments-enabled":true
+				}
+			}]
+		}`))
+	}))
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI:    setup.CLI{Organizations: []string{"test-org"}},
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		if err = (ScrapeWorkspaceSettings{}).Scrape(context.Background(), config, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+	}()
+
+	labels := labelMap{"organization": "test-org", "workspace": "prod"}
+	counterExpected := []MetricResult{
+		{labels: labelMap{"organization": "test-org", "workspace": "prod", "mode": "agent"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range counterExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+}
@@ -16,11 +16,6 @@ import (
 const (
 	// workspaces is the Metric subsystem we use.
 	workspacesSubsystem = "workspaces"
-
-	// TODO: We might want to allow the user to control pageSize via cli/config
-	// 		* This could be handy for users hitting API rate limits (30 per sec).
-	// 		* Investigate performance of (100 requests for 1 item) vs (1 request for 100 items).
-	pageSize = 100
 )
 
 // Metric descriptors.
@@ -54,20 +49,8 @@ func (ScrapeWorkspaces) Version() string {
 	return "v2"
 }
 
-func getWorkspacesListPage(ctx context.Context, page int, organization string, config *setup.Config, ch chan<- prometheus.Metric) error {
-	// include := []tfe.WorkspaceInclude{tfe.WorkspaceIncludeCurrentRun}
-	workspacesList, err := config.Client.Workspaces.List(ctx, organization, &tfe.WorkspaceListOptions{
-		ListOptions: tfe.ListOptions{
-			PageSize:   pageSize,
-			PageNumber: page,
-		},
-		// Include: include,
-	})
-	if err != nil {
-		return fmt.Errorf("%v, (organization=%s, page=%d)", err, organization, page)
-	}
-
-	for _, w := range workspacesList.Items {
+func sendWorkspacesInfo(ctx context.Context, workspaces []*tfe.Workspace, ch chan<- prometheus.Metric) error {
+	for _, w := range workspaces {
 		// level.Info(config.Logger).Log("msg", "Dump Cost", w.CurrentRun.CostEstimate)
 		select {
 		case ch <- prometheus.MustNewConstMetric(
@@ -99,36 +82,38 @@ func getWorkspacesListPage(ctx context.Context, page int, organization string, c
 	return nil
 }
 
+func getWorkspacesInfo(ctx context.Context, organization string, config *setup.Config, ch chan<- prometheus.Metric) error {
+	workspaces, err := listWorkspaces(ctx, organization, config)
+	if err != nil {
+		return err
+	}
+
+	return sendWorkspacesInfo(ctx, workspaces, ch)
+}
+
 // Scrape collects data from Terraform API and sends it over channel as prometheus metric.
 func (ScrapeWorkspaces) Scrape(ctx context.Context, config *setup.Config, ch chan<- prometheus.Metric) error {
 	g, ctx := errgroup.WithContext(ctx)
 	for _, name := range config.Organizations {
 		name := name
 		g.Go(func() error {
-			// TODO: Dummy list call to get the number of workspaces.
-			//       Investigate if there is a better way to get the workspace count.
-			workspacesList, err := config.Client.Workspaces.List(ctx, name, &tfe.WorkspaceListOptions{
-				ListOptions: tfe.ListOptions{PageSize: pageSize},
-			})
-			if err != nil {
-				return fmt.Errorf("%v, organization=%s", err, name)
-			}
-
-			// TODO: We should be able to do some of this work in parallel.
-			//       Investigate potential complications before enabling it.
-			for i := 1; i <= workspacesList.Pagination.TotalPages; i++ {
-				if err := getWorkspacesListPage(ctx, i, name, config, ch); err != nil {
-					return err
-				}
-			}
-
-			return nil
+			return getWorkspacesInfo(ctx, name, config, ch)
 		})
 	}
 
 	return g.Wait()
 }
 
+// listWorkspaces returns every workspace of an organization from the inventory of the collection.
+func listWorkspaces(ctx context.Context, organization string, config *setup.Config) ([]*tfe.Workspace, error) {
+	o, err := getInventory(ctx, config).organization(ctx, organization)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.workspaces, nil
+}
+
 func getCurrentRunID(r *tfe.Run) string {
 	if r == nil {
 		return "na"
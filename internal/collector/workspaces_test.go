@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/ryancbutler/terraform-cloud-exporter/internal/setup"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeWorkspacesPages(t *testing.T) {
+	var mtx sync.Mutex
+	requests := map[string]int{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/test-org/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page[number]")
+		mtx.Lock()
+		requests[page]++
+		mtx.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(fmt.Sprintf(`{
+			"meta":{
+				"pagination":{"current-page":%[1]s,"total-pages":3,"total-count":3}
+			},
+			"data":[{
+				"id":"ws-%[1]s",
+				"type":"workspaces",
+				"attributes":{"name":"ws-%[1]s","terraform-version":"1.5.7"},
+				"relationships":{
+					"organization":{"data":{"id":"test-org","type":"organizations"}},
+					"project":{"data":{"id":"prj-1","type":"projects"}}
+				}
+			}]
+		}`, page)))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mockAPI := httptest.NewServer(mux)
+	defer mockAPI.Close()
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address: mockAPI.URL,
+		Token:   "test",
+	})
+	if err != nil {
+		t.Fatalf("error creating a stub api client: %s", err)
+	}
+
+	config := &setup.Config{
+		Client: *client,
+		CLI: setup.CLI{
+			Organizations:         []string{"test-org"},
+			APIPageSize:           1,
+			WorkspacesPageWorkers: 2,
+		},
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		if err = (ScrapeWorkspaces{}).Scrape(context.Background(), config, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+	}()
+
+	var names []string
+	for m := range ch {
+		got := readMetric(m)
+		convey.Convey("Metrics type", t, func() {
+			convey.So(got.metricType, convey.ShouldEqual, dto.MetricType_GAUGE)
+		})
+		names = append(names, got.labels["name"])
+	}
+	sort.Strings(names)
+
+	convey.Convey("Every page is fetched once", t, func() {
+		convey.So(names, convey.ShouldResemble, []string{"ws-1", "ws-2", "ws-3"})
+		convey.So(requests, convey.ShouldResemble, map[string]int{"1": 1, "2": 1, "3": 1})
+	})
+}
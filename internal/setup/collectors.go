@@ -0,0 +1,55 @@
+package setup
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Collector describes a collector which can be enabled or disabled from the command line.
+type Collector struct {
+	Name           string
+	Help           string
+	EnabledDefault bool
+}
+
+// collectorFlags returns a pointer to a struct holding a negatable --collector.<name> flag per collector,
+// to be added to the CLI as a kong plugin as the collectors are only known at runtime.
+func collectorFlags(collectors []Collector) reflect.Value {
+	fields := make([]reflect.StructField, 0, len(collectors))
+	for i, c := range collectors {
+		help := c.Help + " (disabled by default)"
+		if c.EnabledDefault {
+			help = c.Help + " (enabled by default)"
+		}
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("Collector%d", i),
+			Type: reflect.TypeOf(false),
+			Tag: reflect.StructTag(fmt.Sprintf(`name:"collector.%s" negatable:"" default:"%t" group:"Collectors" help:%q`,
+				c.Name, c.EnabledDefault, help)),
+		})
+	}
+
+	return reflect.New(reflect.StructOf(fields))
+}
+
+// enabledCollectors reads the parsed flags built by collectorFlags.
+func enabledCollectors(collectors []Collector, flags reflect.Value) map[string]bool {
+	enabled := make(map[string]bool, len(collectors))
+	for i, c := range collectors {
+		enabled[c.Name] = flags.Elem().Field(i).Bool()
+	}
+
+	return enabled
+}
+
+func copyCollectors(collectors map[string]bool) map[string]bool {
+	if collectors == nil {
+		return nil
+	}
+
+	copied := make(map[string]bool, len(collectors))
+	for name, enabled := range collectors {
+		copied[name] = enabled
+	}
+	return copied
+}
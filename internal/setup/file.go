@@ -0,0 +1,222 @@
+package setup
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the content of the YAML config file. Its settings override the flags of the same name,
+// listen_address is only read on startup.
+type File struct {
+	Organizations          []string                        `yaml:"organizations"`
+	OrganizationsInclude   string                          `yaml:"organizations_include"`
+	OrganizationsExclude   string                          `yaml:"organizations_exclude"`
+	OrganizationsRefresh   time.Duration                   `yaml:"organizations_refresh"`
+	APIToken               string                          `yaml:"api_token"`
+	APITokenFile           string                          `yaml:"api_token_file"`
+	APIAddress             string                          `yaml:"api_address"`
+	APIInsecureSkipVerify  *bool                           `yaml:"api_insecure_skip_verify"`
+	APIRateLimit           *float64                        `yaml:"api_rate_limit"`
+	APIRateBurst           int                             `yaml:"api_rate_burst"`
+	APIPageSize            int                             `yaml:"api_page_size"`
+	ListenAddress          string                          `yaml:"listen_address"`
+	LogLevel               string                          `yaml:"log_level"`
+	LogFormat              string                          `yaml:"log_format"`
+	CollectionInterval     *time.Duration                  `yaml:"collection_interval"`
+	CollectorIntervals     map[string]time.Duration        `yaml:"collector_intervals"`
+	WorkspacesPageWorkers  int                             `yaml:"workspaces_page_workers"`
+	WorkspaceOutputsName   string                          `yaml:"workspace_outputs_name"`
+	WorkspaceOutputsTags   []string                        `yaml:"workspace_outputs_tags"`
+	WorkspaceLockThreshold time.Duration                   `yaml:"workspace_lock_threshold"`
+	WorkspaceIdleThreshold time.Duration                   `yaml:"workspace_idle_threshold"`
+	AuditTrailToken        string                          `yaml:"audit_trail_token"`
+	Instance               string                          `yaml:"instance"`
+	Instances              map[string]InstanceSettings     `yaml:"instances"`
+	Collectors             map[string]bool                 `yaml:"collectors"`
+	OrganizationSettings   map[string]OrganizationSettings `yaml:"organization_settings"`
+}
+
+// OrganizationSettings are the settings which can be overridden per organization.
+type OrganizationSettings struct {
+	Collectors             map[string]bool `yaml:"collectors"`
+	WorkspaceOutputsName   string          `yaml:"workspace_outputs_name"`
+	WorkspaceOutputsTags   []string        `yaml:"workspace_outputs_tags"`
+	WorkspaceLockThreshold time.Duration   `yaml:"workspace_lock_threshold"`
+	WorkspaceIdleThreshold time.Duration   `yaml:"workspace_idle_threshold"`
+}
+
+// readFile parses a config file, rejecting unknown settings.
+func readFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file := &File{}
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// loadFile applies the config file, if any, on top of the current settings.
+func (c *Config) loadFile() error {
+	if c.ConfigFile == "" {
+		return nil
+	}
+
+	file, err := readFile(c.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	if err := c.checkCollectors(file.Collectors); err != nil {
+		return err
+	}
+	for name, settings := range file.OrganizationSettings {
+		if err := c.checkCollectors(settings.Collectors); err != nil {
+			return fmt.Errorf("%v, (organization=%s)", err, name)
+		}
+	}
+
+	switch file.LogLevel {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("invalid log_level %q, one of: [debug,info,warn,error]", file.LogLevel)
+	}
+	switch file.LogFormat {
+	case "", "logfmt", "json":
+	default:
+		return fmt.Errorf("invalid log_format %q, one of: [logfmt,json]", file.LogFormat)
+	}
+	if file.CollectionInterval != nil && *file.CollectionInterval <= 0 {
+		return fmt.Errorf("invalid collection_interval %s, it must be positive", *file.CollectionInterval)
+	}
+
+	if file.APITokenFile != "" {
+		token, err := readToken(file.APITokenFile)
+		if err != nil {
+			return err
+		}
+		c.APIToken = token
+	} else if file.APIToken != "" {
+		c.APIToken = file.APIToken
+	}
+
+	if file.Organizations != nil {
+		c.Organizations = file.Organizations
+	}
+	if file.OrganizationsInclude != "" {
+		c.OrganizationsInclude = file.OrganizationsInclude
+	}
+	if file.OrganizationsExclude != "" {
+		c.OrganizationsExclude = file.OrganizationsExclude
+	}
+	if file.OrganizationsRefresh != 0 {
+		c.OrganizationsRefresh = file.OrganizationsRefresh
+	}
+	if file.APIAddress != "" {
+		c.APIAddress = file.APIAddress
+	}
+	if file.APIInsecureSkipVerify != nil {
+		c.APIInsecureSkipVerify = *file.APIInsecureSkipVerify
+	}
+	if file.APIRateLimit != nil {
+		c.APIRateLimit = *file.APIRateLimit
+	}
+	if file.APIRateBurst != 0 {
+		c.APIRateBurst = file.APIRateBurst
+	}
+	if file.APIPageSize != 0 {
+		c.APIPageSize = file.APIPageSize
+	}
+	if file.CollectorIntervals != nil {
+		// Don't alter the intervals of the flags, the file is applied on top of them again on reload.
+		intervals := make(map[string]time.Duration, len(c.CollectorIntervals)+len(file.CollectorIntervals))
+		for name, interval := range c.CollectorIntervals {
+			intervals[name] = interval
+		}
+		for name, interval := range file.CollectorIntervals {
+			intervals[name] = interval
+		}
+		c.CollectorIntervals = intervals
+	}
+	if file.WorkspacesPageWorkers != 0 {
+		c.WorkspacesPageWorkers = file.WorkspacesPageWorkers
+	}
+	if file.ListenAddress != "" {
+		c.ListenAddress = file.ListenAddress
+	}
+	if file.LogLevel != "" {
+		c.LogLevel = file.LogLevel
+	}
+	if file.LogFormat != "" {
+		c.LogFormat = file.LogFormat
+	}
+	if file.CollectionInterval != nil {
+		c.CollectionInterval = *file.CollectionInterval
+	}
+	if file.WorkspaceOutputsName != "" {
+		c.WorkspaceOutputsName = file.WorkspaceOutputsName
+	}
+	if file.WorkspaceOutputsTags != nil {
+		c.WorkspaceOutputsTags = file.WorkspaceOutputsTags
+	}
+	if file.WorkspaceLockThreshold != 0 {
+		c.WorkspaceLockThreshold = file.WorkspaceLockThreshold
+	}
+	if file.WorkspaceIdleThreshold != 0 {
+		c.WorkspaceIdleThreshold = file.WorkspaceIdleThreshold
+	}
+	if file.AuditTrailToken != "" {
+		c.AuditTrailToken = file.AuditTrailToken
+	}
+	for name, enabled := range file.Collectors {
+		c.Collectors[name] = enabled
+	}
+	if file.Instance != "" {
+		c.Instance = file.Instance
+	}
+	for name := range file.Instances {
+		if name == "" || name == c.Instance {
+			return fmt.Errorf("invalid instance name %q, it must be set and differ from the one of the flags", name)
+		}
+	}
+	c.Instances = file.Instances
+	c.OrganizationSettings = file.OrganizationSettings
+
+	return nil
+}
+
+// checkCollectors rejects the collector names which don't exist.
+func (c *Config) checkCollectors(collectors map[string]bool) error {
+	for name := range collectors {
+		known := false
+		for _, collector := range c.collectors {
+			known = known || collector.Name == name
+		}
+		if !known {
+			return fmt.Errorf("unknown collector %q", name)
+		}
+	}
+	return nil
+}
+
+func readToken(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan()
+	return scanner.Text(), scanner.Err()
+}
@@ -0,0 +1,137 @@
+package setup
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+var testCollectors = []Collector{
+	{Name: "workspaces", EnabledDefault: true},
+	{Name: "team_access", EnabledDefault: false},
+}
+
+// testConfig returns a Config as parsed from the default flags, reading the given config file.
+func testConfig(t *testing.T, content string) Config {
+	flags := testFlags()
+	flags.ConfigFile = writeConfigFile(t, content)
+	collectors := map[string]bool{"workspaces": true, "team_access": false}
+	return Config{
+		CLI:            flags,
+		Collectors:     copyCollectors(collectors),
+		Logger:         log.NewNopLogger(),
+		flags:          flags,
+		flagCollectors: collectors,
+		collectors:     testCollectors,
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	convey.Convey("The file overrides the flags", t, func() {
+		config := testConfig(t, `
+organizations: [org-a, org-b]
+api_page_size: 50
+workspace_lock_threshold: 2h
+collectors:
+  team_access: true
+`)
+		convey.So(config.loadFile(), convey.ShouldBeNil)
+		convey.So(config.Organizations, convey.ShouldResemble, []string{"org-a", "org-b"})
+		convey.So(config.APIPageSize, convey.ShouldEqual, 50)
+		convey.So(config.WorkspaceLockThreshold, convey.ShouldEqual, 2*time.Hour)
+		convey.So(config.WorkspacesPageWorkers, convey.ShouldEqual, 4)
+		convey.So(config.Collectors, convey.ShouldResemble, map[string]bool{"workspaces": true, "team_access": true})
+		convey.So(config.flagCollectors["team_access"], convey.ShouldBeFalse)
+	})
+
+	convey.Convey("Organization settings override the global ones", t, func() {
+		config := testConfig(t, `
+workspace_lock_threshold: 2h
+organization_settings:
+  org-b:
+    collectors:
+      workspaces: false
+    workspace_lock_threshold: 30m
+`)
+		convey.So(config.loadFile(), convey.ShouldBeNil)
+		convey.So(config.ForOrganization("org-a").WorkspaceLockThreshold, convey.ShouldEqual, 2*time.Hour)
+		convey.So(config.ForOrganization("org-b").WorkspaceLockThreshold, convey.ShouldEqual, 30*time.Minute)
+		convey.So(config.CollectorEnabled("workspaces", "org-a"), convey.ShouldBeTrue)
+		convey.So(config.CollectorEnabled("workspaces", "org-b"), convey.ShouldBeFalse)
+		convey.So(config.Collectors["workspaces"], convey.ShouldBeTrue)
+	})
+
+	convey.Convey("Collector intervals are merged with the ones of the flags", t, func() {
+		config := testConfig(t, "collector_intervals: {team_access: 2h}")
+		config.CollectorIntervals = map[string]time.Duration{"workspaces": time.Hour, "team_access": time.Hour}
+		config.flags.CollectorIntervals = config.CollectorIntervals
+		convey.So(config.loadFile(), convey.ShouldBeNil)
+		convey.So(config.validate(), convey.ShouldBeNil)
+		convey.So(config.CollectorInterval("workspaces"), convey.ShouldEqual, time.Hour)
+		convey.So(config.CollectorInterval("team_access"), convey.ShouldEqual, 2*time.Hour)
+		convey.So(config.flags.CollectorIntervals["team_access"], convey.ShouldEqual, time.Hour)
+	})
+
+	convey.Convey("Collector intervals must be at least the collection interval", t, func() {
+		config := testConfig(t, "collector_intervals: {team_access: 1m}")
+		convey.So(config.loadFile(), convey.ShouldBeNil)
+		convey.So(config.validate(), convey.ShouldNotBeNil)
+
+		config = testConfig(t, "collector_intervals: {unknown: 1h}")
+		convey.So(config.loadFile(), convey.ShouldBeNil)
+		convey.So(config.validate(), convey.ShouldNotBeNil)
+	})
+
+	invalid := map[string]string{
+		"unknown setting":                      "workspace_lock: 2h",
+		"unknown collector":                    "collectors: {unknown: true}",
+		"unknown collector of an organization": "organization_settings: {org-a: {collectors: {unknown: true}}}",
+		"unknown organization setting":         "organization_settings: {org-a: {api_token: secret}}",
+		"invalid log level":                    "log_level: verbose",
+		"instance named like the flags one":    "instances: {default: {api_token: secret}}",
+	}
+	convey.Convey("Invalid files are rejected", t, func() {
+		for name, content := range invalid {
+			content := content
+			convey.Convey(name, func() {
+				config := testConfig(t, content)
+				convey.So(config.loadFile(), convey.ShouldNotBeNil)
+			})
+		}
+	})
+}
+
+func TestReload(t *testing.T) {
+	mockAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockAPI.Close()
+
+	config := testConfig(t, "api_token: test\napi_address: "+mockAPI.URL+"\ncollection_interval: 10m\n")
+
+	convey.Convey("A reload applies the file again on top of the flags", t, func() {
+		reloaded, err := config.Reload()
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(reloaded.CollectionInterval, convey.ShouldEqual, 10*time.Minute)
+		convey.So(reloaded.APIToken, convey.ShouldEqual, "test")
+		config = reloaded
+
+		writeFile(t, config.ConfigFile, "api_token: test\napi_address: "+mockAPI.URL+"\n")
+		reloaded, err = config.Reload()
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(reloaded.CollectionInterval, convey.ShouldEqual, 5*time.Minute)
+	})
+
+	convey.Convey("A failed reload keeps the current config", t, func() {
+		writeFile(t, config.ConfigFile, "api_token: test\ncollection_interval: 10m\ncollectors: {unknown: true}\n")
+		reloaded, err := config.Reload()
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(reloaded.CollectionInterval, convey.ShouldEqual, config.CollectionInterval)
+		convey.So(reloaded.APIAddress, convey.ShouldEqual, mockAPI.URL)
+	})
+}
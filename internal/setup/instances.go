@@ -0,0 +1,65 @@
+package setup
+
+import (
+	"fmt"
+	"sort"
+)
+
+// InstanceSettings describe a Terraform Cloud/Enterprise instance to scrape besides the one of the flags.
+// All the other settings are shared with it.
+type InstanceSettings struct {
+	Organizations         []string `yaml:"organizations"`
+	APIToken              string   `yaml:"api_token"`
+	APITokenFile          string   `yaml:"api_token_file"`
+	APIAddress            string   `yaml:"api_address"`
+	APIInsecureSkipVerify bool     `yaml:"api_insecure_skip_verify"`
+	AuditTrailToken       string   `yaml:"audit_trail_token"`
+}
+
+// ForInstances returns a Config per instance to scrape, each with its own clients, sorted by name.
+// The instance of the flags is left out when it has no API token but others are configured.
+func (c Config) ForInstances() ([]Config, error) {
+	var configs []Config
+	if !c.skipInstance() {
+		configs = append(configs, c)
+	}
+
+	names := make([]string, 0, len(c.Instances))
+	for name := range c.Instances {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		settings := c.Instances[name]
+		config := c
+		config.Instance = name
+		config.Organizations = settings.Organizations
+		config.APIAddress = settings.APIAddress
+		config.APIInsecureSkipVerify = settings.APIInsecureSkipVerify
+		config.AuditTrailToken = settings.AuditTrailToken
+		config.AuditTrailClient = nil
+
+		config.APIToken = settings.APIToken
+		if settings.APITokenFile != "" {
+			token, err := readToken(settings.APITokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("%v, (instance=%s)", err, name)
+			}
+			config.APIToken = token
+		}
+
+		if err := config.setupClient(); err != nil {
+			return nil, fmt.Errorf("%v, (instance=%s)", err, name)
+		}
+		configs = append(configs, config)
+	}
+
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Instance < configs[j].Instance })
+	return configs, nil
+}
+
+// skipInstance tells whether the instance of the flags is left out.
+func (c Config) skipInstance() bool {
+	return c.APIToken == "" && len(c.Instances) > 0
+}
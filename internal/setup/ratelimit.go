@@ -0,0 +1,165 @@
+package setup
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+// Metric descriptors.
+var (
+	apiThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tf",
+		Subsystem: "exporter",
+		Name:      "api_throttled_total",
+		Help:      "Total number of API requests rejected with a 429 Too Many Requests.",
+	}, []string{"instance"})
+	apiRateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "tf",
+		Subsystem: "exporter",
+		Name:      "api_ratelimit_remaining",
+		Help:      "Number of API requests left in the current rate limit window, as reported by X-RateLimit-Remaining.",
+	}, []string{"instance"})
+)
+
+func init() {
+	prometheus.MustRegister(apiThrottled, apiRateLimitRemaining)
+}
+
+// rateLimits holds the rate limit state of each instance, so that it survives the clients rebuilt on reload.
+var rateLimits = struct {
+	sync.Mutex
+	instances map[string]*rateLimit
+}{instances: map[string]*rateLimit{}}
+
+// rateLimit is the rate limit state of an instance, shared by all of its clients.
+type rateLimit struct {
+	limiter *rate.Limiter
+
+	mtx sync.Mutex
+	// until is when the API accepts requests again.
+	until time.Time
+}
+
+// rateLimitFor returns the rate limit state of an instance, applying the given limit and burst to it.
+// A hold back in progress is kept.
+func rateLimitFor(instance string, limit float64, burst int) *rateLimit {
+	rateLimits.Lock()
+	defer rateLimits.Unlock()
+
+	l, ok := rateLimits.instances[instance]
+	if !ok {
+		l = &rateLimit{limiter: rate.NewLimiter(rate.Inf, 0)}
+		rateLimits.instances[instance] = l
+	}
+
+	if limit > 0 {
+		if burst < 1 {
+			burst = 1
+		}
+		l.limiter.SetBurst(burst)
+		l.limiter.SetLimit(rate.Limit(limit))
+	} else {
+		l.limiter.SetLimit(rate.Inf)
+	}
+	return l
+}
+
+// rateLimitedTransport limits the requests sent to an instance with a token bucket, and holds all of them back
+// once the API tells it is throttling. Its state is shared by all the clients of the instance, across reloads.
+type rateLimitedTransport struct {
+	next     http.RoundTripper
+	instance string
+	*rateLimit
+}
+
+func newRateLimitedTransport(next http.RoundTripper, limit float64, burst int, instance string) *rateLimitedTransport {
+	return &rateLimitedTransport{next: next, instance: instance, rateLimit: rateLimitFor(instance, limit, burst)}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.wait(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining, err := strconv.ParseFloat(resp.Header.Get("X-RateLimit-Remaining"), 64)
+	hasRemaining := err == nil
+	if hasRemaining {
+		apiRateLimitRemaining.WithLabelValues(t.instance).Set(remaining)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		apiThrottled.WithLabelValues(t.instance).Inc()
+		t.holdBack(retryDelay(resp.Header))
+	} else if hasRemaining && remaining <= 0 {
+		t.holdBack(resetDelay(resp.Header))
+	}
+
+	return resp, nil
+}
+
+// wait blocks until the API accepts requests again and the token bucket allows the request.
+func (t *rateLimitedTransport) wait(req *http.Request) error {
+	t.mtx.Lock()
+	delay := time.Until(t.until)
+	t.mtx.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return req.Context().Err()
+		}
+	}
+
+	return t.limiter.Wait(req.Context())
+}
+
+func (l *rateLimit) holdBack(delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if until := time.Now().Add(delay); until.After(l.until) {
+		l.until = until
+	}
+}
+
+// retryDelay reads how long to wait before retrying from Retry-After, in seconds or as a date,
+// falling back to X-RateLimit-Reset.
+func retryDelay(header http.Header) time.Duration {
+	if v := header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		if date, err := http.ParseTime(v); err == nil {
+			return time.Until(date)
+		}
+	}
+
+	return resetDelay(header)
+}
+
+// resetDelay reads X-RateLimit-Reset, the seconds until the rate limit window resets.
+func resetDelay(header http.Header) time.Duration {
+	seconds, err := strconv.ParseFloat(header.Get("X-RateLimit-Reset"), 64)
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,146 @@
+package setup
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// resetRateLimit forgets the rate limit state of an instance, which outlives the clients.
+func resetRateLimit(instance string) {
+	rateLimits.Lock()
+	defer rateLimits.Unlock()
+	delete(rateLimits.instances, instance)
+}
+
+// readValue returns the value of a counter or gauge.
+func readValue(m prometheus.Metric) float64 {
+	pb := &dto.Metric{}
+	m.Write(pb)
+	if pb.Counter != nil {
+		return pb.Counter.GetValue()
+	}
+	return pb.Gauge.GetValue()
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  http.Header
+		delay   time.Duration
+		isReset bool
+	}{
+		{"seconds", http.Header{"Retry-After": {"30"}}, 30 * time.Second, false},
+		{"date", http.Header{"Retry-After": {time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}}, time.Minute, false},
+		{"invalid falls back to the reset", http.Header{"Retry-After": {"soon"}, "X-Ratelimit-Reset": {"1.5"}}, 1500 * time.Millisecond, false},
+		{"reset", http.Header{"X-Ratelimit-Reset": {"0.25"}}, 250 * time.Millisecond, true},
+		{"none", http.Header{}, 0, true},
+	}
+
+	convey.Convey("Delays are read from the headers", t, func() {
+		for _, test := range tests {
+			// HTTP dates are rounded to the second.
+			convey.So(retryDelay(test.header), convey.ShouldAlmostEqual, test.delay, time.Second)
+			if test.isReset {
+				convey.So(resetDelay(test.header), convey.ShouldEqual, test.delay)
+			}
+		}
+	})
+}
+
+func TestRateLimitedTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		header    http.Header
+		throttled float64
+		remaining float64
+		holdBack  time.Duration
+	}{
+		{"accepted", http.StatusOK, http.Header{"X-Ratelimit-Remaining": {"20"}}, 0, 20, 0},
+		{"throttled", http.StatusTooManyRequests, http.Header{"Retry-After": {"2"}, "X-Ratelimit-Remaining": {"0"}}, 1, 0, 2 * time.Second},
+		{"throttled with a reset", http.StatusTooManyRequests, http.Header{"X-Ratelimit-Reset": {"3"}}, 1, 0, 3 * time.Second},
+		{"exhausted", http.StatusOK, http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"4"}}, 0, 0, 4 * time.Second},
+	}
+
+	for _, test := range tests {
+		test := test
+		mockAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for name, values := range test.header {
+				w.Header()[name] = values
+			}
+			w.WriteHeader(test.status)
+		}))
+
+		instance := "test-" + test.name
+		resetRateLimit(instance)
+		throttled := readValue(apiThrottled.WithLabelValues(instance))
+		transport := newRateLimitedTransport(http.DefaultTransport, 0, 0, instance)
+		req, _ := http.NewRequest("GET", mockAPI.URL, nil)
+		resp, err := transport.RoundTrip(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		mockAPI.Close()
+
+		convey.Convey("The transport follows the rate limit headers: "+test.name, t, func() {
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, test.status)
+			convey.So(readValue(apiThrottled.WithLabelValues(instance))-throttled, convey.ShouldEqual, test.throttled)
+			convey.So(readValue(apiRateLimitRemaining.WithLabelValues(instance)), convey.ShouldEqual, test.remaining)
+			if test.holdBack == 0 {
+				convey.So(transport.until.IsZero(), convey.ShouldBeTrue)
+			} else {
+				convey.So(time.Until(transport.until), convey.ShouldAlmostEqual, test.holdBack, time.Second)
+			}
+		})
+	}
+}
+
+func TestRateLimitedTransportWait(t *testing.T) {
+	mockAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockAPI.Close()
+
+	convey.Convey("The token bucket spaces the requests out", t, func() {
+		resetRateLimit("test-bucket")
+		transport := newRateLimitedTransport(http.DefaultTransport, 20, 1, "test-bucket")
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			req, _ := http.NewRequest("GET", mockAPI.URL, nil)
+			resp, err := transport.RoundTrip(req)
+			convey.So(err, convey.ShouldBeNil)
+			resp.Body.Close()
+		}
+		convey.So(time.Since(start), convey.ShouldBeGreaterThanOrEqualTo, 90*time.Millisecond)
+	})
+
+	convey.Convey("Requests are held back until the API accepts them again", t, func() {
+		resetRateLimit("test-hold-back")
+		transport := newRateLimitedTransport(http.DefaultTransport, 0, 0, "test-hold-back")
+		transport.holdBack(time.Hour)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, "GET", mockAPI.URL, nil)
+		_, err := transport.RoundTrip(req)
+		convey.So(errors.Is(err, context.DeadlineExceeded), convey.ShouldBeTrue)
+	})
+
+	convey.Convey("The hold back is kept when the clients are rebuilt on reload", t, func() {
+		resetRateLimit("test-reload")
+		newRateLimitedTransport(http.DefaultTransport, 0, 0, "test-reload").holdBack(time.Hour)
+		transport := newRateLimitedTransport(http.DefaultTransport, 10, 10, "test-reload")
+		convey.So(time.Until(transport.until), convey.ShouldBeGreaterThan, 59*time.Minute)
+		convey.So(float64(transport.limiter.Limit()), convey.ShouldEqual, 10)
+	})
+}
@@ -3,8 +3,11 @@ package setup
 import (
 	"bufio"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
+	"regexp"
 	"time"
 
 	"github.com/go-kit/log"
@@ -16,31 +19,204 @@ import (
 )
 
 type CLI struct {
-	Organizations         []string `short:"o" env:"TF_ORGANIZATIONS" placeholder:"ORG1,ORG2" help:"List of the Organization names to scrape from (Ommit to scrape all)."`
-	APIToken              string   `short:"t" env:"TF_API_TOKEN" help:"User token for autheticating with the API."`
-	APITokenFile          *os.File `placeholder:"/path/to/file" help:"File containing user token for autheticating with the API."`
-	APIAddress            string   `placeholder:"https://app.terraform.io/" help:"Terraform API address to scrape metrics from."`
-	APIInsecureSkipVerify bool     `help:"Accept any certificate presented by the API."`
-	ListenAddress         string   `default:"0.0.0.0:9100" help:"Address to listen on for web interface and telemetry."`
-	LogLevel              string   `default:"info" enum:"debug,info,warn,error" help:"Only log messages with the given severity or above. One of: [${enum}]"`
-	LogFormat             string   `default:"logfmt" enum:"logfmt,json" help:"Output format of log messages. One of: [${enum}]"`
+	Organizations          []string      `short:"o" env:"TF_ORGANIZATIONS" placeholder:"ORG1,ORG2" help:"List of the Organization names to scrape from (Ommit to scrape all)."`
+	OrganizationsInclude   string        `placeholder:"REGEX" help:"Only scrape the discovered organizations whose name matches this regular expression."`
+	OrganizationsExclude   string        `placeholder:"REGEX" help:"Skip the discovered organizations whose name matches this regular expression."`
+	OrganizationsRefresh   time.Duration `default:"1h" help:"Interval between two discoveries of the organizations, when none are listed."`
+	APIToken               string        `short:"t" env:"TF_API_TOKEN" help:"User token for autheticating with the API."`
+	APITokenFile           *os.File      `placeholder:"/path/to/file" help:"File containing user token for autheticating with the API."`
+	APIAddress             string        `placeholder:"https://app.terraform.io/" help:"Terraform API address to scrape metrics from."`
+	APIInsecureSkipVerify  bool          `help:"Accept any certificate presented by the API."`
+	APIRateLimit           float64       `default:"30" help:"Maximum number of API requests per second (0 for no limit)."`
+	APIRateBurst           int           `default:"30" help:"Maximum number of API requests sent at once within the rate limit."`
+	APIPageSize            int           `default:"100" help:"Number of items per page of the API lists, up to 100."`
+	ListenAddress          string        `default:"0.0.0.0:9100" help:"Address to listen on for web interface and telemetry."`
+	LogLevel               string        `default:"info" enum:"debug,info,warn,error" help:"Only log messages with the given severity or above. One of: [${enum}]"`
+	LogFormat              string        `default:"logfmt" enum:"logfmt,json" help:"Output format of log messages. One of: [${enum}]"`
+	CollectionInterval     time.Duration `default:"5m" help:"Interval between two collections of the metrics from the Terraform API, served from cache in between."`
+	WorkspacesPageWorkers  int           `default:"4" help:"Maximum number of workspace pages fetched at once per organization."`
+	WorkspaceOutputsName   string        `placeholder:"REGEX" help:"Export numeric and boolean outputs of the workspaces whose name matches this regular expression."`
+	WorkspaceOutputsTags   []string      `placeholder:"TAG1,TAG2" help:"Export numeric and boolean outputs of the workspaces having all of these tags."`
+	WorkspaceLockThreshold time.Duration `default:"1h" help:"Flag workspaces locked for longer than this duration."`
+	WorkspaceIdleThreshold time.Duration `default:"2160h" help:"Count workspaces without any change nor apply for longer than this duration as idle."`
+	AuditTrailToken        string        `env:"TF_AUDIT_TRAIL_TOKEN" help:"Organization token used to read the audit trail (Omit to skip it)."`
+	Instance               string        `default:"default" help:"Value of the instance label of the metrics scraped from the API address above."`
+	ConfigFile             string        `name:"config.file" placeholder:"/path/to/config.yml" help:"YAML configuration file, its settings override the flags. Reloaded on SIGHUP or a POST to /-/reload."`
+
+	// CollectorIntervals are given like --collector-intervals="billable_resources=1h;team_access=30m".
+	CollectorIntervals map[string]time.Duration `placeholder:"NAME=DURATION;..." help:"Collect some collectors less often than every collection interval, e.g. billable_resources=1h to save API calls."`
+
+	// Plugins holds the --collector.<name> flags.
+	kong.Plugins
 }
 
 type Config struct {
 	CLI
 	Client tfe.Client
-	Logger log.Logger
+	// AuditTrailClient is only set when an audit trail token is provided.
+	AuditTrailClient *tfe.Client
+	// Collectors tells whether each collector is enabled, by name.
+	Collectors map[string]bool
+	// OrganizationSettings override the settings above for some organizations, by name.
+	OrganizationSettings map[string]OrganizationSettings
+	// Instances are the other instances to scrape, by name.
+	Instances map[string]InstanceSettings
+	Logger    log.Logger
+
+	// flags and flagCollectors are the parsed command line, the config file is applied on top of them on reload.
+	flags          CLI
+	flagCollectors map[string]bool
+	collectors     []Collector
 }
 
 // NewConfig returns a new Config object that was initialized according to the CLI params.
-func NewConfig() Config {
-	config := Config{}
+// Each of the given collectors gets its own --collector.<name> and --no-collector.<name> flags.
+func NewConfig(collectors []Collector) Config {
+	config := Config{collectors: collectors}
+	flags := collectorFlags(collectors)
+	config.Plugins = kong.Plugins{flags.Interface()}
 	kong.Parse(&config.CLI)
+	config.Collectors = enabledCollectors(collectors, flags)
+
+	if config.APITokenFile != nil {
+		// Read it once, the file isn't opened again on reload.
+		scanner := bufio.NewScanner(config.APITokenFile)
+		scanner.Scan()
+		config.APIToken = scanner.Text()
+		config.APITokenFile.Close()
+		config.APITokenFile = nil
+	}
+	config.flags = config.CLI
+	config.flagCollectors = copyCollectors(config.Collectors)
+
+	// The logger is needed to report errors, set it up from the flags first.
+	config.setupLogger()
+	if err := config.loadFile(); err != nil {
+		level.Error(config.Logger).Log("msg", "Error loading config file", "file", config.ConfigFile, "err", err)
+		os.Exit(1)
+	}
+	if err := config.validate(); err != nil {
+		level.Error(config.Logger).Log("msg", "Invalid config", "err", err)
+		os.Exit(1)
+	}
 	config.setupLogger()
-	config.setupClient()
+	if config.skipInstance() {
+		return config
+	}
+	if err := config.setupClient(); err != nil {
+		level.Error(config.Logger).Log("msg", "Error creating tfe client", "err", err)
+		os.Exit(1)
+	}
 	return config
 }
 
+// Reload returns a new Config from the command line and the current content of the config file.
+func (c Config) Reload() (Config, error) {
+	config := Config{
+		CLI:            c.flags,
+		Collectors:     copyCollectors(c.flagCollectors),
+		Logger:         c.Logger,
+		flags:          c.flags,
+		flagCollectors: c.flagCollectors,
+		collectors:     c.collectors,
+	}
+
+	if err := config.loadFile(); err != nil {
+		return c, err
+	}
+	if err := config.validate(); err != nil {
+		return c, err
+	}
+	config.setupLogger()
+	if config.skipInstance() {
+		return config, nil
+	}
+	if err := config.setupClient(); err != nil {
+		return c, err
+	}
+	return config, nil
+}
+
+// ForOrganization returns the Config to use for an organization, with its own settings applied.
+func (c Config) ForOrganization(name string) Config {
+	settings, ok := c.OrganizationSettings[name]
+	if !ok {
+		return c
+	}
+
+	// Without any collector setting, all of them are enabled anyway.
+	if c.Collectors != nil {
+		collectors := copyCollectors(c.Collectors)
+		for collector, enabled := range settings.Collectors {
+			collectors[collector] = enabled
+		}
+		c.Collectors = collectors
+	}
+
+	if settings.WorkspaceOutputsName != "" {
+		c.WorkspaceOutputsName = settings.WorkspaceOutputsName
+	}
+	if settings.WorkspaceOutputsTags != nil {
+		c.WorkspaceOutputsTags = settings.WorkspaceOutputsTags
+	}
+	if settings.WorkspaceLockThreshold != 0 {
+		c.WorkspaceLockThreshold = settings.WorkspaceLockThreshold
+	}
+	if settings.WorkspaceIdleThreshold != 0 {
+		c.WorkspaceIdleThreshold = settings.WorkspaceIdleThreshold
+	}
+	return c
+}
+
+// CollectorInterval returns the interval between two collections of a collector.
+func (c Config) CollectorInterval(collector string) time.Duration {
+	if interval, ok := c.CollectorIntervals[collector]; ok {
+		return interval
+	}
+	return c.CollectionInterval
+}
+
+// CollectorEnabled tells whether a collector is enabled for an organization, collectors are enabled unless told otherwise.
+func (c Config) CollectorEnabled(collector, organization string) bool {
+	config := c.ForOrganization(organization)
+	if config.Collectors == nil {
+		return true
+	}
+	return config.Collectors[collector]
+}
+
+// validate checks the settings the API would reject.
+func (c *Config) validate() error {
+	if c.CollectionInterval <= 0 {
+		return fmt.Errorf("invalid collection interval %s, it must be positive", c.CollectionInterval)
+	}
+	for name, interval := range c.CollectorIntervals {
+		if err := c.checkCollectors(map[string]bool{name: true}); err != nil {
+			return err
+		}
+		// Collectors run along the collection cycles, they can't run more often.
+		if interval < c.CollectionInterval {
+			return fmt.Errorf("invalid interval %s of collector %q, it must be at least the collection interval %s", interval, name, c.CollectionInterval)
+		}
+	}
+	if c.APIPageSize < 1 || c.APIPageSize > 100 {
+		return fmt.Errorf("invalid API page size %d, it must be between 1 and 100", c.APIPageSize)
+	}
+	if _, err := regexp.Compile(c.OrganizationsInclude); err != nil {
+		return fmt.Errorf("invalid organizations include filter: %v", err)
+	}
+	if _, err := regexp.Compile(c.OrganizationsExclude); err != nil {
+		return fmt.Errorf("invalid organizations exclude filter: %v", err)
+	}
+	if c.OrganizationsRefresh <= 0 {
+		return fmt.Errorf("invalid organizations refresh interval %s, it must be positive", c.OrganizationsRefresh)
+	}
+	if c.WorkspacesPageWorkers < 1 {
+		return fmt.Errorf("invalid number of workspaces page workers %d, it must be at least 1", c.WorkspacesPageWorkers)
+	}
+	return nil
+}
+
 func (c *Config) setupLogger() {
 	// Changes timestamp from 9 variable to 3 fixed
 	// decimals (.130 instead of .130987456).
@@ -69,39 +245,43 @@ func (c *Config) setupLogger() {
 	c.Logger = log.With(c.Logger, "ts", timestampFormat, "caller", log.DefaultCaller)
 }
 
-func (c *Config) setupClient() {
+func (c *Config) setupClient() error {
 	config := &tfe.Config{}
 
-	if c.APITokenFile != nil {
-		defer c.APITokenFile.Close()
-		scanner := bufio.NewScanner(c.APITokenFile)
-		scanner.Scan()
-		config.Token = scanner.Text()
-	} else if c.APIToken != "" {
-		config.Token = c.APIToken
-	} else {
-		level.Error(c.Logger).Log("msg", "Error creating tfe client", "err", "Missing API Token.")
-		os.Exit(1)
+	if c.APIToken == "" {
+		return errors.New("missing API token")
 	}
+	config.Token = c.APIToken
 
 	if c.APIAddress != "" {
 		config.Address = c.APIAddress
 		level.Info(c.Logger).Log("msg", "Overwritten Terraform API address", "address", c.APIAddress)
 	}
 
+	transport := http.DefaultTransport.(*http.Transport).Clone()
 	if c.APIInsecureSkipVerify {
-		config.HTTPClient = &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: c.APIInsecureSkipVerify},
-			},
-		}
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: c.APIInsecureSkipVerify}
 		level.Warn(c.Logger).Log("msg", "HTTP InsecureSkipVerify is enabled.")
 	}
+	config.HTTPClient = &http.Client{
+		Transport: newRateLimitedTransport(transport, c.APIRateLimit, c.APIRateBurst, c.Instance),
+	}
 
 	client, err := tfe.NewClient(config)
 	if err != nil {
-		level.Error(c.Logger).Log("msg", "Error creating tfe client", "err", err)
-		os.Exit(1)
+		return err
 	}
 	c.Client = *client
+
+	if c.AuditTrailToken != "" {
+		// The audit trail is only readable with an organization token, which can't be used for the rest of the API.
+		auditTrailConfig := *config
+		auditTrailConfig.Token = c.AuditTrailToken
+		c.AuditTrailClient, err = tfe.NewClient(&auditTrailConfig)
+		if err != nil {
+			return fmt.Errorf("audit trail client: %v", err)
+		}
+	}
+
+	return nil
 }
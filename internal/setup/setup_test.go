@@ -0,0 +1,87 @@
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// testFlags are valid flags, as parsed with their defaults.
+func testFlags() CLI {
+	return CLI{
+		APIPageSize:            100,
+		LogLevel:               "info",
+		LogFormat:              "logfmt",
+		CollectionInterval:     5 * time.Minute,
+		WorkspacesPageWorkers:  4,
+		WorkspaceLockThreshold: time.Hour,
+		WorkspaceIdleThreshold: 2160 * time.Hour,
+		OrganizationsRefresh:   time.Hour,
+		Instance:               "default",
+	}
+}
+
+// writeConfigFile writes a config file in a temporary directory and returns its path.
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeFile(t, path, content)
+	return path
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("error writing the config file: %s", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags func(*CLI)
+		valid bool
+	}{
+		{"defaults", func(*CLI) {}, true},
+		{"zero collection interval", func(c *CLI) { c.CollectionInterval = 0 }, false},
+		{"negative collection interval", func(c *CLI) { c.CollectionInterval = -time.Minute }, false},
+		{"page size above 100", func(c *CLI) { c.APIPageSize = 101 }, false},
+		{"no page workers", func(c *CLI) { c.WorkspacesPageWorkers = 0 }, false},
+		{"invalid organizations filter", func(c *CLI) { c.OrganizationsInclude = "(" }, false},
+	}
+
+	convey.Convey("Flags validation", t, func() {
+		for _, test := range tests {
+			config := Config{CLI: testFlags()}
+			test.flags(&config.CLI)
+			err := config.validate()
+			convey.So(err == nil, convey.ShouldEqual, test.valid)
+		}
+	})
+}
+
+func TestLoadFileCollectionInterval(t *testing.T) {
+	tests := []struct {
+		content  string
+		valid    bool
+		interval time.Duration
+	}{
+		{"collection_interval: 10m", true, 10 * time.Minute},
+		{"log_level: debug", true, 5 * time.Minute},
+		{"collection_interval: 0s", false, 0},
+		{"collection_interval: -1m", false, 0},
+	}
+
+	convey.Convey("The collection interval of the file must be positive", t, func() {
+		for _, test := range tests {
+			config := Config{CLI: testFlags(), Collectors: map[string]bool{}}
+			config.ConfigFile = writeConfigFile(t, test.content)
+			err := config.loadFile()
+			convey.So(err == nil, convey.ShouldEqual, test.valid)
+			if test.valid {
+				convey.So(config.CollectionInterval, convey.ShouldEqual, test.interval)
+			}
+		}
+	})
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/go-kit/log/level"
@@ -25,44 +28,127 @@ var (
 	BuildDate string
 )
 
-func newHandler(metrics collector.Metrics, config setup.Config) http.HandlerFunc {
+func newHandler(instances *collector.Instances) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Like mysqld_exporter, collect[] selects the collectors to serve, organization the organizations.
+		params := r.URL.Query()
+		filter := collector.Filter{
+			Collectors:    params["collect[]"],
+			Organizations: params["organization"],
+			Instances:     params["instance"],
+		}
+		if err := filter.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		registry := prometheus.NewRegistry()
+		if err := instances.Register(registry, filter); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		gatherers := prometheus.Gatherers{
+			prometheus.DefaultGatherer,
+			registry,
+		}
+		// Delegate http serving to Prometheus client library, which will call collector.Collect.
+		// The exporter only serves the results of its last background collection, so no API call is made here:
+		// filtering restricts what is served, --collector-intervals how often each collector calls the API.
+		h := promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{})
+		h.ServeHTTP(w, r)
+	}
+}
+
+func newProbeHandler(instances *collector.Instances) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		target := params.Get("target")
+		if target == "" {
+			http.Error(w, "Target parameter is missing", http.StatusBadRequest)
+			return
+		}
+		// Like blackbox_exporter modules, module selects the instance to probe.
+		module := params.Get("module")
+		if module == "" {
+			module = instances.DefaultInstance()
+		}
+		exporter := instances.Exporter(module)
+		if exporter == nil {
+			http.Error(w, fmt.Sprintf("Unknown module %q", module), http.StatusBadRequest)
+			return
+		}
+
 		// Use request context for cancellation when connection gets closed.
 		ctx := r.Context()
 		// If a timeout is configured via the Prometheus header, add it to the context.
 		if v := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"); v != "" {
 			timeoutSeconds, err := strconv.ParseFloat(v, 64)
 			if err != nil {
-				level.Error(config.Logger).Log("msg", "Failed to parse timeout from Prometheus header", "err", err)
+				level.Error(exporter.Config().Logger).Log("msg", "Failed to parse timeout from Prometheus header", "err", err)
 			} else {
-				// Create new timeout context with request context as parent.
-				ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSeconds*float64(time.Second)))
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutSeconds*float64(time.Second)))
 				defer cancel()
-				// Overwrite request with timeout context.
-				r = r.WithContext(ctx)
 			}
 		}
 
 		registry := prometheus.NewRegistry()
-		registry.MustRegister(collector.New(ctx, config, metrics))
-
-		gatherers := prometheus.Gatherers{
-			prometheus.DefaultGatherer,
-			registry,
-		}
-		// Delegate http serving to Prometheus client library, which will call collector.Collect.
-		h := promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{})
+		// Label the probed metrics with their instance, like the ones of /metrics.
+		wrapped := prometheus.WrapRegistererWith(prometheus.Labels{"instance": module}, registry)
+		wrapped.MustRegister(exporter.Probe(ctx, target))
+		h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
 		h.ServeHTTP(w, r)
 	}
 }
 
+// reload applies the current config file, the previous config is kept on errors.
+func reload(instances *collector.Instances) error {
+	config := instances.Config()
+	if err := instances.Reload(); err != nil {
+		level.Error(config.Logger).Log("msg", "Error reloading config", "file", config.ConfigFile, "err", err)
+		return err
+	}
+	level.Info(instances.Config().Logger).Log("msg", "Reloaded config", "file", config.ConfigFile)
+	return nil
+}
+
 func main() {
-	config := setup.NewConfig()
+	config := setup.NewConfig(collector.Collectors())
 	level.Info(config.Logger).Log("msg", "Starting tf_exporter", "version", Version, "revision", Commit)
 	level.Debug(config.Logger).Log("msg", "Build Context", "go", GoVersion, "date", BuildDate)
+	for _, c := range collector.Collectors() {
+		if config.Collectors[c.Name] {
+			level.Info(config.Logger).Log("msg", "Collector enabled", "collector", c.Name)
+		}
+	}
+
+	instances, err := collector.NewInstances(context.Background(), config)
+	if err != nil {
+		level.Error(config.Logger).Log("msg", "Error creating instances", "err", err)
+		os.Exit(1)
+	}
 
-	handlerFunc := newHandler(collector.NewMetrics(), config)
-	http.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, handlerFunc))
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reload(instances)
+		}
+	}()
+
+	http.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, newHandler(instances)))
+	http.Handle("/probe", newProbeHandler(instances))
+	http.HandleFunc("/-/reload", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			w.Write([]byte("This endpoint requires a POST or PUT request.\n"))
+			return
+		}
+		if err := reload(instances); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to reload config: %s", err), http.StatusInternalServerError)
+		}
+	})
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`<html>
 			<head><title>Terraform Cloud/Enterprise Exporter</title></head>